@@ -0,0 +1,364 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/metadata"
+	"gopkg.in/yaml.v2"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/collections"
+)
+
+//go:generate go run ./cmd/configgen -i chaincode-config.yaml -o chaincode-config-bundled.go
+
+// The environment variables configuring the chaincode. They override the configuration bundled from
+// chaincode-config.yaml and, when the chaincode runs as a service with access to its own files, the file named by
+// configFileEnv, a JSON file or, when its name ends in .yaml or .yml, a YAML file. Features are toggled with
+// CMC_FEATURE_<NAME> variables such as CMC_FEATURE_PURGE=false
+const (
+	configFileEnv         = "CMC_CONFIG_FILE"
+	titleEnv              = "CMC_TITLE"
+	descriptionEnv        = "CMC_DESCRIPTION"
+	licenseNameEnv        = "CMC_LICENSE_NAME"
+	licenseURLEnv         = "CMC_LICENSE_URL"
+	contactNameEnv        = "CMC_CONTACT_NAME"
+	contactEmailEnv       = "CMC_CONTACT_EMAIL"
+	contactURLEnv         = "CMC_CONTACT_URL"
+	collectionStrategyEnv = "CMC_COLLECTION_STRATEGY"
+	defaultCollectionEnv  = "CMC_DEFAULT_COLLECTION"
+	logLevelEnv           = "CMC_LOG_LEVEL"
+	featureEnvPrefix      = "CMC_FEATURE_"
+)
+
+// The strategies naming the collection of a transaction that does not request one in its transient data
+const (
+	// strategyImplicit uses the implicit collection of the organization of the client
+	strategyImplicit = "implicit"
+	// strategyShared uses the default collection, which must be one of the named collections
+	strategyShared = "shared"
+)
+
+var logLevels = []string{"debug", "info", "warning", "error"}
+
+// featureTransactions lists the transactions of each optional feature. Every feature is enabled unless disabled in the
+// configuration, and the transactions of a disabled feature are refused
+var featureTransactions = map[string][]string{
+	"tokens":    {"ClientAccountID", "Mint", "Burn", "Transfer", "BalanceOf", "TotalSupply", "Approve", "Allowance", "TransferFrom"},
+	"utxo":      {"MintUTXO", "TransferUTXO", "UTXOsOf"},
+	"transfers": {"AgreeToSellCryptoMotionCoin", "AgreeToBuyCryptoMotionCoin", "TransferCryptoMotionCoin"},
+	"holds":     {"HoldCryptoMotionCoin", "ReleaseHold", "ExecuteHold", "DeliverHold", "ExpireHold", "ReadHold"},
+	"batch":     {"BatchCryptoMotionCoins"},
+	"purge":     {"PurgeCryptoMotionCoin"},
+	"migration": {"MigrateCryptoMotionCoins"},
+}
+
+type licenseConfig struct {
+	Name string `json:"name" yaml:"name"`
+	URL  string `json:"url" yaml:"url"`
+}
+
+type contactConfig struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+	URL   string `json:"url" yaml:"url"`
+}
+
+// contractConfig is the information published in the metadata of the contract
+type contractConfig struct {
+	Title       string        `json:"title" yaml:"title"`
+	Description string        `json:"description" yaml:"description"`
+	License     licenseConfig `json:"license" yaml:"license"`
+	Contact     contactConfig `json:"contact" yaml:"contact"`
+}
+
+type collectionsConfig struct {
+	Strategy string `json:"strategy" yaml:"strategy"`
+	Default  string `json:"default" yaml:"default"`
+}
+
+// chaincodeConfig holds the settings that may differ between the channels the chaincode is deployed to
+type chaincodeConfig struct {
+	Contract    contractConfig    `json:"contract" yaml:"contract"`
+	Collections collectionsConfig `json:"collections" yaml:"collections"`
+	Features    map[string]bool   `json:"features" yaml:"features"`
+	LogLevel    string            `json:"logLevel" yaml:"logLevel"`
+}
+
+// activeConfig is the configuration of the running chaincode, loaded at startup
+var activeConfig = defaultChaincodeConfig()
+
+// defaultChaincodeConfig returns the configuration bundled into the chaincode. The bundled configuration is checked by
+// the tests, so failing to parse it is a build defect
+func defaultChaincodeConfig() *chaincodeConfig {
+	config := &chaincodeConfig{Collections: collectionsConfig{Strategy: strategyImplicit}, LogLevel: "info"}
+
+	err := config.parse("chaincode-config.yaml", []byte(bundledConfig))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return config
+}
+
+// loadChaincodeConfig returns the bundled configuration overridden by the configuration file, if any, and then by the
+// environment. The result is validated
+func loadChaincodeConfig(getenv func(string) string) (*chaincodeConfig, error) {
+	config := defaultChaincodeConfig()
+
+	if path := getenv(configFileEnv); path != "" {
+		err := config.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := config.readEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (c *chaincodeConfig) readFile(path string) error {
+	document, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Could not read the configuration file. %s", err)
+	}
+
+	return c.parse(path, document)
+}
+
+// parse overrides the configuration with the settings of a document, which is YAML when its name ends in .yaml or .yml
+// and JSON otherwise
+func (c *chaincodeConfig) parse(path string, document []byte) error {
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(document, c)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(document))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Could not parse the configuration file %s. %s", path, err)
+	}
+
+	if c.Features == nil {
+		c.Features = map[string]bool{}
+	}
+
+	return nil
+}
+
+func (c *chaincodeConfig) readEnv(getenv func(string) string) error {
+	settings := []struct {
+		env   string
+		value *string
+	}{
+		{titleEnv, &c.Contract.Title},
+		{descriptionEnv, &c.Contract.Description},
+		{licenseNameEnv, &c.Contract.License.Name},
+		{licenseURLEnv, &c.Contract.License.URL},
+		{contactNameEnv, &c.Contract.Contact.Name},
+		{contactEmailEnv, &c.Contract.Contact.Email},
+		{contactURLEnv, &c.Contract.Contact.URL},
+		{collectionStrategyEnv, &c.Collections.Strategy},
+		{defaultCollectionEnv, &c.Collections.Default},
+		{logLevelEnv, &c.LogLevel},
+	}
+
+	for _, setting := range settings {
+		if value := getenv(setting.env); value != "" {
+			*setting.value = value
+		}
+	}
+
+	for feature := range featureTransactions {
+		env := featureEnvPrefix + strings.ToUpper(feature)
+
+		value := getenv(env)
+		if value == "" {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false but is %s", env, value)
+		}
+
+		c.Features[feature] = enabled
+	}
+
+	return nil
+}
+
+// validate returns an error listing every invalid setting, or nil when the configuration is valid
+func (c *chaincodeConfig) validate() error {
+	var problems []string
+
+	if version == "" {
+		problems = append(problems, "the version set at build time must not be blank")
+	}
+
+	if c.Contract.Title == "" {
+		problems = append(problems, "contract.title must not be blank")
+	}
+
+	if c.Contract.License.URL != "" && !isAbsoluteURL(c.Contract.License.URL) {
+		problems = append(problems, fmt.Sprintf("contract.license.url %s is not an absolute URL", c.Contract.License.URL))
+	}
+
+	if c.Contract.Contact.URL != "" && !isAbsoluteURL(c.Contract.Contact.URL) {
+		problems = append(problems, fmt.Sprintf("contract.contact.url %s is not an absolute URL", c.Contract.Contact.URL))
+	}
+
+	if c.Contract.Contact.Email != "" {
+		if _, err := mail.ParseAddress(c.Contract.Contact.Email); err != nil {
+			problems = append(problems, fmt.Sprintf("contract.contact.email %s is not an email address", c.Contract.Contact.Email))
+		}
+	}
+
+	switch c.Collections.Strategy {
+	case strategyImplicit:
+		if c.Collections.Default != "" {
+			problems = append(problems, "collections.default is only used by the shared strategy")
+		}
+	case strategyShared:
+		if _, exists := collectionConfigs.Lookup(c.Collections.Default); !exists {
+			problems = append(problems, fmt.Sprintf("collections.default %q must name one of the collections of collections.json", c.Collections.Default))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("collections.strategy %q must be %s or %s", c.Collections.Strategy, strategyImplicit, strategyShared))
+	}
+
+	var unknown []string
+
+	for feature := range c.Features {
+		if _, exists := featureTransactions[feature]; !exists {
+			unknown = append(unknown, feature)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	for _, feature := range unknown {
+		problems = append(problems, fmt.Sprintf("features.%s is not a feature", feature))
+	}
+
+	if !containsString(logLevels, c.LogLevel) {
+		problems = append(problems, fmt.Sprintf("logLevel %q must be one of %s", c.LogLevel, strings.Join(logLevels, ", ")))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("The configuration is not valid. %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func isAbsoluteURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.IsAbs() && parsed.Host != ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLogged reports whether messages of a level are logged at the configured log level
+func (c *chaincodeConfig) isLogged(level string) bool {
+	for _, l := range logLevels {
+		if l == c.LogLevel {
+			return true
+		} else if l == level {
+			return false
+		}
+	}
+
+	return false
+}
+
+// isFeatureEnabled reports whether the transactions of a feature may be submitted
+func (c *chaincodeConfig) isFeatureEnabled(feature string) bool {
+	enabled, set := c.Features[feature]
+	return !set || enabled
+}
+
+// defaultCollectionName returns the collection of a transaction that does not request one
+func (c *chaincodeConfig) defaultCollectionName(mspid string) string {
+	if c.Collections.Strategy == strategyShared {
+		return c.Collections.Default
+	}
+
+	return collections.ImplicitPrefix + mspid
+}
+
+// applyTo sets the metadata of the contract and chaincode
+func (c *chaincodeConfig) applyTo(contract *CryptoMotionCoinContract) {
+	contract.Info.Version = version
+	contract.Info.Description = c.Contract.Description
+	contract.Info.License = &metadata.LicenseMetadata{Name: c.Contract.License.Name, URL: c.Contract.License.URL}
+	contract.Info.Contact = &metadata.ContactMetadata{Name: c.Contract.Contact.Name, Email: c.Contract.Contact.Email, URL: c.Contract.Contact.URL}
+}
+
+// getTransactionName returns the name of the transaction function being called, without the contract namespace
+func getTransactionName(ctx contractapi.TransactionContextInterface) string {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+
+	if function == "" {
+		return ""
+	}
+
+	name := []rune(function)
+	name[0] = unicode.ToUpper(name[0])
+
+	return string(name)
+}
+
+// checkFeatureEnabled refuses the transactions of disabled features. It runs before every transaction dispatched to the contract
+func checkFeatureEnabled(ctx contractapi.TransactionContextInterface) error {
+	transaction := getTransactionName(ctx)
+
+	for feature, transactions := range featureTransactions {
+		if containsString(transactions, transaction) && !activeConfig.isFeatureEnabled(feature) {
+			return cmcerrors.Unauthorizedf("Access denied. The %s feature is disabled on this channel", feature)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+func writeTestConfig(t *testing.T, directory string, name string, document string) string {
+	path := filepath.Join(directory, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(document), 0600))
+
+	return path
+}
+
+func TestLoadChaincodeConfig(t *testing.T) {
+	directory, err := ioutil.TempDir("", "chaincode-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	env := map[string]string{}
+	getenv := func(key string) string {
+		return env[key]
+	}
+
+	config, err := loadChaincodeConfig(getenv)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultChaincodeConfig(), config, "should use the defaults without a file or environment")
+
+	env[configFileEnv] = writeTestConfig(t, directory, "config.yaml", `
+contract:
+  description: Channel one
+  contact:
+    email: ops@example.com
+collections:
+  strategy: shared
+  default: CryptoMotionCoinShared
+features:
+  purge: false
+logLevel: warning
+`)
+	config, err = loadChaincodeConfig(getenv)
+	assert.NoError(t, err, "should read YAML files")
+	assert.Equal(t, "Channel one", config.Contract.Description)
+	assert.Equal(t, "John Doe", config.Contract.Contact.Name, "should keep the defaults the file does not set")
+	assert.Equal(t, "ops@example.com", config.Contract.Contact.Email)
+	assert.Equal(t, collectionsConfig{Strategy: strategyShared, Default: "CryptoMotionCoinShared"}, config.Collections)
+	assert.False(t, config.isFeatureEnabled("purge"), "should disable features")
+	assert.True(t, config.isFeatureEnabled("batch"), "should enable the other features")
+	assert.Equal(t, "warning", config.LogLevel)
+
+	env[configFileEnv] = writeTestConfig(t, directory, "config.json", `{"contract": {"title": "Channel two"}, "features": {"utxo": false}}`)
+	env[descriptionEnv] = "From the environment"
+	env[featureEnvPrefix+"UTXO"] = "true"
+	env[featureEnvPrefix+"HOLDS"] = "false"
+	config, err = loadChaincodeConfig(getenv)
+	assert.NoError(t, err, "should read JSON files")
+	assert.Equal(t, "Channel two", config.Contract.Title)
+	assert.Equal(t, "From the environment", config.Contract.Description, "should let the environment override the defaults")
+	assert.True(t, config.isFeatureEnabled("utxo"), "should let the environment override the file")
+	assert.False(t, config.isFeatureEnabled("holds"))
+
+	env[featureEnvPrefix+"HOLDS"] = "no"
+	_, err = loadChaincodeConfig(getenv)
+	assert.EqualError(t, err, "CMC_FEATURE_HOLDS must be true or false but is no", "should reject invalid feature toggles")
+	delete(env, featureEnvPrefix+"HOLDS")
+
+	env[configFileEnv] = writeTestConfig(t, directory, "typo.json", `{"logLevl": "debug"}`)
+	_, err = loadChaincodeConfig(getenv)
+	assert.Contains(t, err.Error(), "Could not parse the configuration file", "should reject unknown settings")
+
+	env[configFileEnv] = filepath.Join(directory, "missing.yaml")
+	_, err = loadChaincodeConfig(getenv)
+	assert.Contains(t, err.Error(), "Could not read the configuration file.", "should report missing files")
+
+	env[configFileEnv] = writeTestConfig(t, directory, "invalid.yml", `
+contract:
+  title: ""
+  license:
+    url: apache.org
+  contact:
+    email: nobody
+collections:
+  default: CryptoMotionCoinShared
+features:
+  lasers: true
+`)
+	env[logLevelEnv] = "verbose"
+	_, err = loadChaincodeConfig(getenv)
+	assert.EqualError(t, err, "The configuration is not valid. contract.title must not be blank; "+
+		"contract.license.url apache.org is not an absolute URL; contract.contact.email nobody is not an email address; "+
+		"collections.default is only used by the shared strategy; features.lasers is not a feature; "+
+		`logLevel "verbose" must be one of debug, info, warning, error`, "should list every invalid setting")
+
+	env[configFileEnv] = ""
+	env[logLevelEnv] = ""
+	env[collectionStrategyEnv] = strategyShared
+	env[defaultCollectionEnv] = "Unknown"
+	_, err = loadChaincodeConfig(getenv)
+	assert.EqualError(t, err, `The configuration is not valid. collections.default "Unknown" must name one of the collections of collections.json`)
+
+	env[collectionStrategyEnv] = "random"
+	_, err = loadChaincodeConfig(getenv)
+	assert.EqualError(t, err, `The configuration is not valid. collections.strategy "random" must be implicit or shared`)
+}
+
+func TestBundledConfig(t *testing.T) {
+	document, err := ioutil.ReadFile("chaincode-config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, string(document), bundledConfig, "should bundle the current configuration file. Run go generate after editing it")
+
+	config := defaultChaincodeConfig()
+	assert.NoError(t, config.validate(), "should bundle a valid configuration")
+	assert.Equal(t, "Create-BlockchainNetwork-IBPV20 chaincode", config.Contract.Title)
+	assert.Equal(t, strategyImplicit, config.Collections.Strategy)
+	assert.Equal(t, map[string]bool{}, config.Features)
+}
+
+func TestIsLogged(t *testing.T) {
+	config := &chaincodeConfig{LogLevel: "warning"}
+
+	assert.False(t, config.isLogged("debug"))
+	assert.False(t, config.isLogged("info"))
+	assert.True(t, config.isLogged("warning"), "should log messages of the configured level")
+	assert.True(t, config.isLogged("error"), "should log messages above the configured level")
+}
+
+func TestApplyTo(t *testing.T) {
+	config := defaultChaincodeConfig()
+	config.Contract.Contact.Email = "ops@example.com"
+
+	contract := new(CryptoMotionCoinContract)
+	config.applyTo(contract)
+
+	assert.Equal(t, version, contract.Info.Version, "should publish the build version")
+	assert.Equal(t, "My Private Data Smart Contract", contract.Info.Description)
+	assert.Equal(t, "Apache-2.0", contract.Info.License.Name)
+	assert.Equal(t, "ops@example.com", contract.Info.Contact.Email)
+
+	_, err := contractapi.NewChaincode(contract)
+	assert.NoError(t, err, "should produce valid contract metadata")
+}
+
+func TestDefaultCollectionStrategy(t *testing.T) {
+	defer func(config *chaincodeConfig) {
+		activeConfig = config
+	}(activeConfig)
+
+	activeConfig = defaultChaincodeConfig()
+	activeConfig.Collections = collectionsConfig{Strategy: strategyShared, Default: "CryptoMotionCoinShared"}
+
+	ledger := ledgertest.NewLedger()
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	outsider := newScenarioIdentity(t, "Org3MSP", "issuer3", roleIssuer)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+
+	create := func(ctx contractapi.TransactionContextInterface) error {
+		return new(CryptoMotionCoinContract).CreateCryptoMotionCoin(ctx, "coin1")
+	}
+
+	assert.NoError(t, ledger.Submit(issuer, transient, create))
+	assert.NotNil(t, ledger.PrivateData("CryptoMotionCoinShared", "coin1"), "should store assets in the default collection")
+	assert.Nil(t, ledger.PrivateData("_implicit_org_Org1MSP", "coin1"), "should not use the implicit collection")
+
+	err := ledger.Submit(outsider, transient, create)
+	assert.EqualError(t, err, "[UNAUTHORIZED] The organization Org3MSP is not a member of the collection CryptoMotionCoinShared", "should only use the default collection for its members")
+}
+
+func TestCheckFeatureEnabled(t *testing.T) {
+	defer func(config *chaincodeConfig) {
+		activeConfig = config
+	}(activeConfig)
+
+	activeConfig = defaultChaincodeConfig()
+	activeConfig.Features["purge"] = false
+
+	contract := new(CryptoMotionCoinContract)
+	contract.BeforeTransaction = checkFeatureEnabled
+
+	chaincode, err := contractapi.NewChaincode(contract)
+	require.NoError(t, err)
+
+	ledger := ledgertest.NewLedger()
+	admin := newScenarioIdentity(t, "Org1MSP", "admin1", roleAdmin)
+
+	response := ledger.Invoke(chaincode, admin, nil, "PurgeCryptoMotionCoin", "coin1")
+	assert.Equal(t, "[UNAUTHORIZED] Access denied. The purge feature is disabled on this channel", response.Message, "should refuse the transactions of disabled features")
+
+	response = ledger.Invoke(chaincode, admin, nil, "CryptoMotionCoinContract:purgeCryptoMotionCoin", "coin1")
+	assert.Equal(t, "[UNAUTHORIZED] Access denied. The purge feature is disabled on this channel", response.Message, "should match transactions called with a namespace or a lower case name")
+
+	response = ledger.Invoke(chaincode, admin, nil, "ReadCryptoMotionCoin", "coin1")
+	assert.Equal(t, "[NOT_FOUND] The asset coin1 does not exist", response.Message, "should let the transactions of enabled features run")
+}
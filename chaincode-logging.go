@@ -0,0 +1,282 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// logOutput receives the log entries of the chaincode, one JSON object per line. Tests replace it to inspect the entries
+var logOutput io.Writer = os.Stderr
+
+// logClock returns the time of the log entries and transaction durations. Tests replace it to make them predictable
+var logClock = time.Now
+
+var logMutex sync.Mutex
+
+// redactedValue replaces the private values found in log entries
+const redactedValue = "[REDACTED]"
+
+// logFields are the structured fields of a log entry. Their values are strings and numbers
+type logFields map[string]interface{}
+
+// writeLog writes an entry when its level is logged at the configured log level
+func writeLog(level string, message string, fields logFields) {
+	if !activeConfig.isLogged(level) {
+		return
+	}
+
+	entry := logFields{}
+
+	for key, value := range fields {
+		entry[key] = value
+	}
+
+	entry["time"] = logClock().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level
+	entry["message"] = message
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	logOutput.Write(append(line, '\n'))
+}
+
+// transactionTrace holds what is logged about a transaction: its fields, when it started and the values of its
+// transient data, which are redacted from every entry
+type transactionTrace struct {
+	started  time.Time
+	fields   logFields
+	redacted []string
+}
+
+func newTransactionTrace(stub shim.ChaincodeStubInterface) *transactionTrace {
+	function, _ := stub.GetFunctionAndParameters()
+
+	trace := &transactionTrace{
+		started: logClock(),
+		fields:  logFields{"txID": stub.GetTxID(), "channel": stub.GetChannelID(), "function": function},
+	}
+
+	if identity, err := cid.New(stub); err == nil {
+		if mspid, err := identity.GetMSPID(); err == nil {
+			trace.fields["mspID"] = mspid
+		}
+	}
+
+	if transient, err := stub.GetTransient(); err == nil {
+		trace.redacted = getRedactedValues(transient)
+	}
+
+	return trace
+}
+
+func (t *transactionTrace) setCollection(collectionName string) {
+	t.fields["collection"] = collectionName
+}
+
+// duration returns the milliseconds elapsed since the transaction started
+func (t *transactionTrace) duration() float64 {
+	return float64(logClock().Sub(t.started)) / float64(time.Millisecond)
+}
+
+// log writes an entry with the fields of the transaction, redacting its transient values from the message and fields
+func (t *transactionTrace) log(level string, message string, fields logFields) {
+	entry := logFields{}
+
+	for _, source := range []logFields{t.fields, fields} {
+		for key, value := range source {
+			if text, ok := value.(string); ok {
+				value = redact(text, t.redacted)
+			}
+
+			entry[key] = value
+		}
+	}
+
+	writeLog(level, redact(message, t.redacted), entry)
+}
+
+// getRedactedValues returns the values of the transient data that must never be logged: each value and, for JSON
+// values, each string and number they contain, longest first. The requested collection is not private and is kept
+func getRedactedValues(transient map[string][]byte) []string {
+	var values []string
+
+	for key, value := range transient {
+		if key == collectionTransientKey {
+			continue
+		}
+
+		values = append(values, string(value))
+
+		var document interface{}
+
+		decoder := json.NewDecoder(bytes.NewReader(value))
+		decoder.UseNumber()
+
+		if decoder.Decode(&document) == nil {
+			values = appendJSONLeaves(values, document)
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	return values
+}
+
+func appendJSONLeaves(values []string, document interface{}) []string {
+	switch value := document.(type) {
+	case map[string]interface{}:
+		for _, element := range value {
+			values = appendJSONLeaves(values, element)
+		}
+	case []interface{}:
+		for _, element := range value {
+			values = appendJSONLeaves(values, element)
+		}
+	case string:
+		values = append(values, value)
+	case json.Number:
+		values = append(values, value.String())
+	}
+
+	return values
+}
+
+// redact replaces the values found in the text. A value is only replaced where it is not part of a longer word, so
+// that the private amount 1 is redacted from "amount 1" but not from "coin1"
+func redact(text string, values []string) string {
+	for _, value := range values {
+		if value != "" {
+			text = redactValue(text, value)
+		}
+	}
+
+	return text
+}
+
+func redactValue(text string, value string) string {
+	var redacted strings.Builder
+
+	start := 0
+
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], value)
+		if i < 0 {
+			break
+		}
+
+		i += offset
+		end := i + len(value)
+
+		startsWord := i == 0 || !isWordByte(text[i-1]) || !isWordByte(value[0])
+		endsWord := end == len(text) || !isWordByte(text[end]) || !isWordByte(value[len(value)-1])
+
+		if startsWord && endsWord {
+			redacted.WriteString(text[start:i])
+			redacted.WriteString(redactedValue)
+			start = end
+			offset = end
+		} else {
+			offset = i + 1
+		}
+	}
+
+	redacted.WriteString(text[start:])
+
+	return redacted.String()
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// traces holds the trace of each transaction invoked through a tracingChaincode, keyed by channel and transaction ID,
+// so that the transaction context shares it
+var traces sync.Map
+
+func getTraceKey(stub shim.ChaincodeStubInterface) string {
+	return stub.GetChannelID() + ":" + stub.GetTxID()
+}
+
+// transactionContext is the transaction context of the contract. It carries the trace of the transaction
+type transactionContext struct {
+	contractapi.TransactionContext
+	trace *transactionTrace
+}
+
+// traceCollection records the collection used by a transaction and returns it
+func traceCollection(ctx contractapi.TransactionContextInterface, collectionName string) string {
+	if traced, ok := ctx.(*transactionContext); ok && traced.trace != nil {
+		traced.trace.setCollection(collectionName)
+	}
+
+	return collectionName
+}
+
+// startTransactionTrace starts the trace of every transaction dispatched to the contract
+func startTransactionTrace(ctx *transactionContext) error {
+	if trace, exists := traces.Load(getTraceKey(ctx.GetStub())); exists {
+		ctx.trace = trace.(*transactionTrace)
+	} else {
+		ctx.trace = newTransactionTrace(ctx.GetStub())
+	}
+
+	ctx.trace.log("debug", "Transaction started", nil)
+
+	return nil
+}
+
+// afterTransaction logs the transactions that succeed. Failed transactions do not reach it and are logged by
+// tracingChaincode
+func afterTransaction(ctx *transactionContext, _ interface{}) error {
+	ctx.trace.log("info", "Transaction completed", logFields{"durationMs": ctx.trace.duration()})
+
+	return nil
+}
+
+// tracingChaincode logs the transactions of a chaincode that fail, including those refused before reaching the
+// contract. Only the code of their error is logged since error messages may quote private values read from the ledger,
+// which are not redacted like transient values
+type tracingChaincode struct {
+	shim.Chaincode
+}
+
+func (c *tracingChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	trace := newTransactionTrace(stub)
+
+	key := getTraceKey(stub)
+	traces.Store(key, trace)
+	defer traces.Delete(key)
+
+	response := c.Chaincode.Invoke(stub)
+
+	if response.Status >= shim.ERRORTHRESHOLD {
+		trace.log("error", "Transaction failed", logFields{"durationMs": trace.duration(), "status": response.Status, "errorCode": string(cmcerrors.ParseCode(response.Message))})
+	}
+
+	return response
+}
@@ -0,0 +1,174 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+// captureLogs sends the log entries to the returned buffer at the log level, with a clock advancing by 5ms on every
+// reading. It returns a function restoring the logger and configuration
+func captureLogs(level string) (*bytes.Buffer, func()) {
+	output, clock, config := logOutput, logClock, activeConfig
+
+	buffer := new(bytes.Buffer)
+	logOutput = buffer
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	logClock = func() time.Time {
+		now = now.Add(5 * time.Millisecond)
+		return now
+	}
+
+	activeConfig = defaultChaincodeConfig()
+	activeConfig.LogLevel = level
+
+	return buffer, func() {
+		logOutput, logClock, activeConfig = output, clock, config
+	}
+}
+
+func readLogEntries(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(buffer.Bytes()))
+	for scanner.Scan() {
+		entry := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), "should write one JSON object per line")
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func newTracedChaincode(t *testing.T) *tracingChaincode {
+	contract := new(CryptoMotionCoinContract)
+	contract.TransactionContextHandler = new(transactionContext)
+	contract.BeforeTransaction = beforeTransaction
+	contract.AfterTransaction = afterTransaction
+
+	chaincode, err := contractapi.NewChaincode(contract)
+	require.NoError(t, err)
+
+	return &tracingChaincode{chaincode}
+}
+
+func TestWriteLog(t *testing.T) {
+	buffer, restore := captureLogs("info")
+	defer restore()
+
+	writeLog("debug", "Hidden", nil)
+	writeLog("info", "Shown", logFields{"count": 2, "level": "overridden"})
+
+	entries := readLogEntries(t, buffer)
+	require.Len(t, entries, 1, "should only write the entries of the configured level and above")
+	assert.Equal(t, map[string]interface{}{
+		"time":    "2020-06-01T12:00:00.005Z",
+		"level":   "info",
+		"message": "Shown",
+		"count":   float64(2),
+	}, entries[0], "should not let fields override the level or message")
+}
+
+func TestRedact(t *testing.T) {
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 7, Denomination: "CMC", Metadata: map[string]string{"note": "top secret"}})
+	transient[collectionTransientKey] = []byte("CryptoMotionCoinShared")
+
+	values := getRedactedValues(transient)
+	assert.Contains(t, values, string(transient[cryptoMotionCoinTransientKey]), "should redact whole values")
+	assert.Contains(t, values, "top secret", "should redact nested strings")
+	assert.Contains(t, values, "7", "should redact numbers")
+	assert.NotContains(t, values, "CryptoMotionCoinShared", "should keep the requested collection")
+
+	assert.Equal(t, "The asset [REDACTED] of [REDACTED] holds [REDACTED] [REDACTED]", redact("The asset top secret of user1 holds 7 CMC", values))
+	assert.Equal(t, "coin17 of user12 in CryptoMotionCoinShared", redact("coin17 of user12 in CryptoMotionCoinShared", values), "should not redact parts of longer words")
+	assert.Equal(t, "Could not parse [REDACTED].", redact("Could not parse "+string(transient[cryptoMotionCoinTransientKey])+".", values))
+}
+
+func TestTransactionTracing(t *testing.T) {
+	buffer, restore := captureLogs("debug")
+	defer restore()
+
+	ledger := ledgertest.NewLedger()
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "owner-of-coin", Amount: 4321, Denomination: "CMC"})
+	chaincode := newTracedChaincode(t)
+
+	response := ledger.Invoke(chaincode, issuer, transient, "CreateCryptoMotionCoin", "coin1")
+	require.Equal(t, "", response.Message)
+
+	response = ledger.Invoke(chaincode, issuer, transient, "CreateCryptoMotionCoin", "coin1")
+	require.Equal(t, "[ALREADY_EXISTS] The asset coin1 already exists", response.Message)
+
+	entries := readLogEntries(t, buffer)
+	require.Len(t, entries, 4)
+
+	for _, entry := range entries {
+		assert.Equal(t, ledgertest.ChannelID, entry["channel"])
+		assert.Equal(t, "Org1MSP", entry["mspID"])
+		assert.Equal(t, "CreateCryptoMotionCoin", entry["function"])
+		assert.NotEmpty(t, entry["txID"])
+	}
+
+	assert.Equal(t, "debug", entries[0]["level"])
+	assert.Equal(t, "Transaction started", entries[0]["message"])
+	assert.Nil(t, entries[0]["collection"], "should not know the collection before the transaction runs")
+
+	assert.Equal(t, "info", entries[1]["level"])
+	assert.Equal(t, "Transaction completed", entries[1]["message"])
+	assert.Equal(t, "_implicit_org_Org1MSP", entries[1]["collection"], "should record the collection used")
+	assert.Equal(t, float64(10), entries[1]["durationMs"], "should time the transaction from the invocation")
+	assert.Equal(t, entries[0]["txID"], entries[1]["txID"])
+
+	assert.Equal(t, "Transaction started", entries[2]["message"])
+	assert.NotEqual(t, entries[0]["txID"], entries[2]["txID"])
+
+	assert.Equal(t, "error", entries[3]["level"])
+	assert.Equal(t, "Transaction failed", entries[3]["message"])
+	assert.Equal(t, "ALREADY_EXISTS", entries[3]["errorCode"], "should log why the transaction failed")
+	assert.NotContains(t, buffer.String(), "The asset coin1 already exists", "should not log error messages since they may hold private values")
+	assert.Equal(t, float64(500), entries[3]["status"])
+	assert.Equal(t, "_implicit_org_Org1MSP", entries[3]["collection"], "should log the collection of failed transactions")
+
+	assert.NotContains(t, buffer.String(), "owner-of-coin", "should never log transient values")
+	assert.NotContains(t, buffer.String(), "4321", "should never log transient values")
+}
+
+func TestTransactionTracingRefused(t *testing.T) {
+	buffer, restore := captureLogs("warning")
+	defer restore()
+
+	activeConfig.Features["purge"] = false
+
+	ledger := ledgertest.NewLedger()
+	admin := newScenarioIdentity(t, "Org1MSP", "admin1", roleAdmin)
+
+	response := ledger.Invoke(newTracedChaincode(t), admin, nil, "PurgeCryptoMotionCoin", "coin1")
+	assert.Equal(t, "[UNAUTHORIZED] Access denied. The purge feature is disabled on this channel", response.Message, "should still refuse the transactions of disabled features")
+
+	entries := readLogEntries(t, buffer)
+	require.Len(t, entries, 1, "should only log failures at the warning level")
+	assert.Equal(t, "Transaction failed", entries[0]["message"])
+	assert.Equal(t, "UNAUTHORIZED", entries[0]["errorCode"])
+
+	response = ledger.Invoke(newTracedChaincode(t), admin, nil, "UnknownTransaction")
+	assert.Equal(t, "Function UnknownTransaction not found in contract CryptoMotionCoinContract", response.Message)
+
+	entries = readLogEntries(t, buffer)
+	require.Len(t, entries, 2, "should log transactions that never reach the contract")
+	assert.Equal(t, "UnknownTransaction", entries[1]["function"])
+	assert.Equal(t, "", entries[1]["errorCode"], "should log a blank code for errors without one")
+}
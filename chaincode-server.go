@@ -0,0 +1,213 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// The environment variables configuring the chaincode-as-a-service mode. The file variables hold paths to PEM files
+const (
+	serverAddressEnv = "CHAINCODE_SERVER_ADDRESS"
+	chaincodeIDEnv   = "CHAINCODE_ID"
+	tlsDisabledEnv   = "CHAINCODE_TLS_DISABLED"
+	tlsKeyEnv        = "CHAINCODE_TLS_KEY"
+	tlsCertEnv       = "CHAINCODE_TLS_CERT"
+	clientCACertEnv  = "CHAINCODE_CLIENT_CA_CERT"
+)
+
+// maxMessageSize matches the limit of the peers on the messages they exchange with chaincode
+const maxMessageSize = 100 * 1024 * 1024
+
+// shutdownTimeout bounds how long a stopping server waits for peers to close their streams before closing them itself
+const shutdownTimeout = 20 * time.Second
+
+// serverConfig holds the settings of the chaincode-as-a-service mode, in which the chaincode runs its own server and the
+// peers connect to it, as required by external builders
+type serverConfig struct {
+	Address  string
+	CCID     string
+	TLSProps shim.TLSProperties
+}
+
+// getServerConfig reads the chaincode-as-a-service settings from the environment. It returns nil when no server address
+// is set, in which case the peer launches the chaincode. TLS is required unless explicitly disabled
+func getServerConfig(getenv func(string) string) (*serverConfig, error) {
+	address := getenv(serverAddressEnv)
+	if address == "" {
+		return nil, nil
+	}
+
+	config := &serverConfig{Address: address, CCID: getenv(chaincodeIDEnv)}
+
+	if config.CCID == "" {
+		return nil, fmt.Errorf("%s must be set when %s is set", chaincodeIDEnv, serverAddressEnv)
+	}
+
+	if disabled := getenv(tlsDisabledEnv); disabled != "" {
+		var err error
+
+		config.TLSProps.Disabled, err = strconv.ParseBool(disabled)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false. %s", tlsDisabledEnv, err)
+		}
+	}
+
+	if config.TLSProps.Disabled {
+		return config, nil
+	}
+
+	files := []struct {
+		env      string
+		required bool
+		contents *[]byte
+	}{
+		{tlsKeyEnv, true, &config.TLSProps.Key},
+		{tlsCertEnv, true, &config.TLSProps.Cert},
+		{clientCACertEnv, false, &config.TLSProps.ClientCACerts},
+	}
+
+	for _, file := range files {
+		path := getenv(file.env)
+		if path == "" {
+			if file.required {
+				return nil, fmt.Errorf("%s must be set unless %s is true", file.env, tlsDisabledEnv)
+			}
+
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read %s. %s", file.env, err)
+		}
+
+		*file.contents = contents
+	}
+
+	return config, nil
+}
+
+// newServerTLSConfig returns the TLS settings of the server, which verifies the certificates of the peers when client CA
+// certificates are given
+func newServerTLSConfig(props shim.TLSProperties) (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair(props.Cert, props.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load the TLS key pair. %s", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:             tls.VersionTLS12,
+		Certificates:           []tls.Certificate{certificate},
+		SessionTicketsDisabled: true,
+	}
+
+	if len(props.ClientCACerts) > 0 {
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(props.ClientCACerts) {
+			return nil, errors.New("Could not load the client CA certificates")
+		}
+
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// chaincodeServer serves a chaincode to the peers through shim.ChaincodeServer. It owns the gRPC server, unlike
+// shim.ChaincodeServer.Start, so it can be stopped gracefully
+type chaincodeServer struct {
+	listener   net.Listener
+	grpcServer *grpc.Server
+}
+
+func newChaincodeServer(config *serverConfig, chaincode shim.Chaincode) (*chaincodeServer, error) {
+	options := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: time.Minute, Timeout: 20 * time.Second}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: time.Minute, PermitWithoutStream: true}),
+		grpc.MaxSendMsgSize(maxMessageSize),
+		grpc.MaxRecvMsgSize(maxMessageSize),
+	}
+
+	if !config.TLSProps.Disabled {
+		tlsConfig, err := newServerTLSConfig(config.TLSProps)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	listener, err := net.Listen("tcp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &chaincodeServer{listener: listener, grpcServer: grpc.NewServer(options...)}
+	pb.RegisterChaincodeServer(server.grpcServer, &shim.ChaincodeServer{CCID: config.CCID, Address: config.Address, CC: chaincode, TLSProps: config.TLSProps})
+
+	return server, nil
+}
+
+// serveUntil serves the chaincode until the server fails or a signal is received. Once signalled, the server stops
+// accepting connections and waits up to shutdownTimeout for the peers to close their streams
+func (s *chaincodeServer) serveUntil(signals <-chan os.Signal) error {
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- s.grpcServer.Serve(s.listener)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-signals:
+	}
+
+	stopped := make(chan struct{})
+
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		s.grpcServer.Stop()
+	}
+
+	return <-errs
+}
+
+// serveChaincode runs the chaincode as a service until it receives SIGTERM or an interrupt
+func serveChaincode(config *serverConfig, chaincode shim.Chaincode) error {
+	server, err := newChaincodeServer(config, chaincode)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(signals)
+
+	return server.serveUntil(signals)
+}
@@ -0,0 +1,147 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// writeTestKeyPair writes a self-signed certificate and its key in PEM files of the directory
+func writeTestKeyPair(t *testing.T, directory string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(directory, "tls.crt")
+	keyPath := filepath.Join(directory, "tls.key")
+
+	require.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600))
+
+	return certPath, keyPath
+}
+
+func TestGetServerConfig(t *testing.T) {
+	directory, err := ioutil.TempDir("", "chaincode-server")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	certPath, keyPath := writeTestKeyPair(t, directory)
+
+	env := map[string]string{}
+	getenv := func(key string) string {
+		return env[key]
+	}
+
+	config, err := getServerConfig(getenv)
+	assert.NoError(t, err)
+	assert.Nil(t, config, "should let the peer launch the chaincode when no server address is set")
+
+	env[serverAddressEnv] = "0.0.0.0:9999"
+	_, err = getServerConfig(getenv)
+	assert.EqualError(t, err, "CHAINCODE_ID must be set when CHAINCODE_SERVER_ADDRESS is set", "should require a chaincode ID")
+
+	env[chaincodeIDEnv] = "cmc:1234"
+	_, err = getServerConfig(getenv)
+	assert.EqualError(t, err, "CHAINCODE_TLS_KEY must be set unless CHAINCODE_TLS_DISABLED is true", "should require TLS by default")
+
+	env[tlsDisabledEnv] = "yes"
+	_, err = getServerConfig(getenv)
+	assert.EqualError(t, err, `CHAINCODE_TLS_DISABLED must be true or false. strconv.ParseBool: parsing "yes": invalid syntax`)
+
+	env[tlsDisabledEnv] = "true"
+	config, err = getServerConfig(getenv)
+	assert.NoError(t, err)
+	assert.Equal(t, &serverConfig{Address: "0.0.0.0:9999", CCID: "cmc:1234", TLSProps: shim.TLSProperties{Disabled: true}}, config, "should serve without TLS when disabled")
+
+	env[tlsDisabledEnv] = "false"
+	env[tlsKeyEnv] = keyPath
+	env[tlsCertEnv] = certPath
+	config, err = getServerConfig(getenv)
+	assert.NoError(t, err)
+	assert.False(t, config.TLSProps.Disabled)
+	assert.NotEmpty(t, config.TLSProps.Key, "should read the key file")
+	assert.NotEmpty(t, config.TLSProps.Cert, "should read the certificate file")
+	assert.Empty(t, config.TLSProps.ClientCACerts, "should not require client CA certificates")
+
+	env[clientCACertEnv] = filepath.Join(directory, "missing.crt")
+	_, err = getServerConfig(getenv)
+	assert.Contains(t, err.Error(), "Could not read CHAINCODE_CLIENT_CA_CERT.", "should report unreadable files")
+
+	env[clientCACertEnv] = certPath
+	config, err = getServerConfig(getenv)
+	assert.NoError(t, err)
+
+	tlsConfig, err := newServerTLSConfig(config.TLSProps)
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth, "should verify the peers when client CA certificates are given")
+
+	_, err = newServerTLSConfig(shim.TLSProperties{Key: []byte("key"), Cert: []byte("cert")})
+	assert.Contains(t, err.Error(), "Could not load the TLS key pair.", "should reject invalid key pairs")
+}
+
+func TestServeUntil(t *testing.T) {
+	chaincode, err := contractapi.NewChaincode(new(CryptoMotionCoinContract))
+	require.NoError(t, err)
+
+	server, err := newChaincodeServer(&serverConfig{Address: "127.0.0.1:0", CCID: "cmc:1234", TLSProps: shim.TLSProperties{Disabled: true}}, chaincode)
+	require.NoError(t, err, "should listen on the address")
+
+	signals := make(chan os.Signal, 1)
+	stopped := make(chan error, 1)
+
+	go func() {
+		stopped <- server.serveUntil(signals)
+	}()
+
+	connection, err := grpc.Dial(server.listener.Addr().String(), grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	require.NoError(t, err, "should accept connections")
+	connection.Close()
+
+	signals <- syscall.SIGTERM
+
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err, "should stop cleanly on SIGTERM")
+	case <-time.After(5 * time.Second):
+		t.Fatal("should stop once signalled")
+	}
+}
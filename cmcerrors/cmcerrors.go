@@ -0,0 +1,123 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package cmcerrors defines the errors returned by the CryptoMotionCoin
+// chaincode. Every error carries a stable code that prefixes its message in
+// square brackets, for example
+//
+//	[NOT_FOUND] The asset coin1 does not exist
+//
+// Go callers test for a kind of error with errors.Is and one of the codes
+// below. Clients that only receive the message, such as those of the Fabric
+// SDKs, read the code back with ParseCode instead of matching on the text,
+// which may change between releases.
+package cmcerrors
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Code identifies a kind of error. A Code is itself an error so it can be used as the target of errors.Is
+type Code string
+
+// The codes of the errors returned by the chaincode. Codes never change once released
+const (
+	// NotFound means the asset, record or other object the transaction refers to does not exist
+	NotFound Code = "NOT_FOUND"
+	// AlreadyExists means the transaction would create an object that exists already
+	AlreadyExists Code = "ALREADY_EXISTS"
+	// InvalidInput means the arguments or transient data of the transaction are not valid
+	InvalidInput Code = "INVALID_INPUT"
+	// Unauthorized means the client is not allowed to submit the transaction or change the object
+	Unauthorized Code = "UNAUTHORIZED"
+	// LedgerError means the ledger could not be read or written, or holds data the chaincode cannot use
+	LedgerError Code = "LEDGER_ERROR"
+	// Internal means the chaincode failed in a way the client cannot correct, such as when encoding a value
+	Internal Code = "INTERNAL"
+)
+
+func (c Code) Error() string {
+	return string(c)
+}
+
+// Error is an error with a code. The message of the error it wraps follows the code
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%s] %s", e.Code, e.Err)
+}
+
+// Unwrap returns the wrapped error so errors.Is and errors.As reach the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error has the given code
+func (e *Error) Is(target error) bool {
+	code, ok := target.(Code)
+	return ok && code == e.Code
+}
+
+func newError(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// NotFoundf returns a NotFound error. Like fmt.Errorf, the %w verb wraps its operand
+func NotFoundf(format string, args ...interface{}) error {
+	return newError(NotFound, format, args...)
+}
+
+// AlreadyExistsf returns an AlreadyExists error. Like fmt.Errorf, the %w verb wraps its operand
+func AlreadyExistsf(format string, args ...interface{}) error {
+	return newError(AlreadyExists, format, args...)
+}
+
+// InvalidInputf returns an InvalidInput error. Like fmt.Errorf, the %w verb wraps its operand
+func InvalidInputf(format string, args ...interface{}) error {
+	return newError(InvalidInput, format, args...)
+}
+
+// Unauthorizedf returns an Unauthorized error. Like fmt.Errorf, the %w verb wraps its operand
+func Unauthorizedf(format string, args ...interface{}) error {
+	return newError(Unauthorized, format, args...)
+}
+
+// LedgerErrorf returns a LedgerError error. Like fmt.Errorf, the %w verb wraps its operand
+func LedgerErrorf(format string, args ...interface{}) error {
+	return newError(LedgerError, format, args...)
+}
+
+// Internalf returns an Internal error. Like fmt.Errorf, the %w verb wraps its operand
+func Internalf(format string, args ...interface{}) error {
+	return newError(Internal, format, args...)
+}
+
+// CodeOf returns the code of an error, or a blank code when the error does not have one
+func CodeOf(err error) Code {
+	var coded *Error
+
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+
+	return ""
+}
+
+var codePattern = regexp.MustCompile(`^\[([A-Z_]+)\] `)
+
+// ParseCode returns the code at the start of an error message received from the chaincode, or a blank code when the
+// message does not start with one
+func ParseCode(message string) Code {
+	match := codePattern.FindStringSubmatch(message)
+	if match == nil {
+		return ""
+	}
+
+	return Code(match[1])
+}
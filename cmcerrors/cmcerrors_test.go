@@ -0,0 +1,52 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cmcerrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	err := NotFoundf("The asset %s does not exist", "coin1")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset coin1 does not exist", "should prefix the message with the code")
+	assert.True(t, errors.Is(err, NotFound), "should match its code")
+	assert.False(t, errors.Is(err, AlreadyExists), "should not match another code")
+
+	assert.True(t, errors.Is(AlreadyExistsf("exists"), AlreadyExists))
+	assert.True(t, errors.Is(InvalidInputf("invalid"), InvalidInput))
+	assert.True(t, errors.Is(Unauthorizedf("denied"), Unauthorized))
+	assert.True(t, errors.Is(LedgerErrorf("failed"), LedgerError))
+	assert.True(t, errors.Is(Internalf("failed"), Internal))
+}
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("peer unavailable")
+
+	err := LedgerErrorf("Could not read from world state. %w", cause)
+	assert.EqualError(t, err, "[LEDGER_ERROR] Could not read from world state. peer unavailable", "should include the message of the cause")
+	assert.True(t, errors.Is(err, LedgerError), "should match its code")
+	assert.True(t, errors.Is(err, cause), "should wrap the cause")
+
+	var coded *Error
+	assert.True(t, errors.As(err, &coded), "should be an Error")
+	assert.Equal(t, LedgerError, coded.Code)
+}
+
+func TestCodeOf(t *testing.T) {
+	assert.Equal(t, Unauthorized, CodeOf(Unauthorizedf("denied")), "should return the code of an error")
+	assert.Equal(t, NotFound, CodeOf(errors.Unwrap(errors.Unwrap(InvalidInputf("%w", NotFoundf("missing"))))), "should return the code of a wrapped error")
+	assert.Equal(t, Code(""), CodeOf(errors.New("plain")), "should return a blank code for errors without one")
+	assert.Equal(t, Code(""), CodeOf(nil))
+}
+
+func TestParseCode(t *testing.T) {
+	assert.Equal(t, NotFound, ParseCode("[NOT_FOUND] The asset coin1 does not exist"), "should read the code of a message")
+	assert.Equal(t, AlreadyExists, ParseCode(AlreadyExistsf("The asset %s already exists", "coin1").Error()), "should read the code of every error")
+	assert.Equal(t, Code(""), ParseCode("The asset coin1 does not exist"), "should return a blank code for messages without one")
+	assert.Equal(t, Code(""), ParseCode("error [NOT_FOUND] later"), "should only read a code at the start of the message")
+}
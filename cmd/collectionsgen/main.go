@@ -0,0 +1,34 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Command collectionsgen writes the collection definitions of the
+// CryptoMotionCoin chaincode to a collections.json file.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"newprogmodelgoprivatecontract/collections"
+)
+
+func main() {
+	output := flag.String("o", "collections.json", "path of the generated collections file")
+	flag.Parse()
+
+	bytes, err := json.MarshalIndent(collections.Default(), "", "    ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not generate collections. "+err.Error())
+		os.Exit(1)
+	}
+
+	err = ioutil.WriteFile(*output, append(bytes, '\n'), 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not write collections. "+err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Command configgen bundles the default configuration of the
+// CryptoMotionCoin chaincode into a Go source file, so the chaincode carries
+// it without reading files at runtime.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	input := flag.String("i", "chaincode-config.yaml", "path of the configuration file to bundle")
+	output := flag.String("o", "chaincode-config-bundled.go", "path of the generated Go file")
+	flag.Parse()
+
+	document, err := ioutil.ReadFile(*input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not read the configuration file. "+err.Error())
+		os.Exit(1)
+	}
+
+	literal := strconv.Quote(string(document))
+	if !strings.Contains(string(document), "`") {
+		literal = "`" + string(document) + "`"
+	}
+
+	source := new(bytes.Buffer)
+	fmt.Fprintf(source, "// Code generated by configgen from %s. DO NOT EDIT.\n\n", filepath.Base(*input))
+	fmt.Fprintf(source, "package main\n\n")
+	fmt.Fprintf(source, "// bundledConfig is the default configuration of the chaincode\n")
+	fmt.Fprintf(source, "const bundledConfig = %s\n", literal)
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not format the generated source. "+err.Error())
+		os.Exit(1)
+	}
+
+	err = ioutil.WriteFile(*output, formatted, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not write the generated source. "+err.Error())
+		os.Exit(1)
+	}
+}
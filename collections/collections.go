@@ -0,0 +1,179 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package collections describes the private data collections used by the
+// CryptoMotionCoin chaincode and renders them in the collections.json format
+// expected by the peer lifecycle commands.
+package collections
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ImplicitPrefix is the prefix of the implicit collection Fabric creates for every organization
+const ImplicitPrefix = "_implicit_org_"
+
+// Config describes a named private data collection shared by one or more organizations
+type Config struct {
+	Name              string
+	Members           []string
+	RequiredPeerCount int
+	MaxPeerCount      int
+	BlockToLive       uint64
+	MemberOnlyRead    bool
+	MemberOnlyWrite   bool
+	EndorsementPolicy string
+}
+
+type endorsementPolicyJSON struct {
+	SignaturePolicy string `json:"signaturePolicy"`
+}
+
+type configJSON struct {
+	Name              string                 `json:"name"`
+	Policy            string                 `json:"policy"`
+	RequiredPeerCount int                    `json:"requiredPeerCount"`
+	MaxPeerCount      int                    `json:"maxPeerCount"`
+	BlockToLive       uint64                 `json:"blockToLive"`
+	MemberOnlyRead    bool                   `json:"memberOnlyRead"`
+	MemberOnlyWrite   bool                   `json:"memberOnlyWrite"`
+	EndorsementPolicy *endorsementPolicyJSON `json:"endorsementPolicy,omitempty"`
+}
+
+// Policy returns the membership policy of the collection, granting access to the member role of each member organization
+func (c Config) Policy() string {
+	principals := make([]string, len(c.Members))
+
+	for i, member := range c.Members {
+		principals[i] = fmt.Sprintf("'%s.member'", member)
+	}
+
+	return "OR(" + strings.Join(principals, ",") + ")"
+}
+
+// HasMember returns true when the organization with the given MSP ID is a member of the collection
+func (c Config) HasMember(mspid string) bool {
+	for _, member := range c.Members {
+		if member == mspid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate returns an error when the collection could not be accepted by a peer
+func (c Config) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("The collection name must be specified")
+	} else if strings.HasPrefix(c.Name, ImplicitPrefix) {
+		return fmt.Errorf("The collection name %s is reserved for implicit collections", c.Name)
+	} else if len(c.Members) == 0 {
+		return fmt.Errorf("The collection %s must have at least one member", c.Name)
+	} else if c.RequiredPeerCount < 0 {
+		return fmt.Errorf("The collection %s must not have a negative requiredPeerCount", c.Name)
+	} else if c.MaxPeerCount < c.RequiredPeerCount {
+		return fmt.Errorf("The collection %s must have a maxPeerCount of at least %d", c.Name, c.RequiredPeerCount)
+	}
+
+	for _, member := range c.Members {
+		if member == "" {
+			return fmt.Errorf("The collection %s has a blank member", c.Name)
+		}
+	}
+
+	return nil
+}
+
+// Configs is the set of named collections defined for the chaincode
+type Configs []Config
+
+// Lookup returns the collection with the given name
+func (cs Configs) Lookup(name string) (Config, bool) {
+	for _, c := range cs {
+		if c.Name == name {
+			return c, true
+		}
+	}
+
+	return Config{}, false
+}
+
+// Validate returns an error when any collection is invalid or two collections share a name
+func (cs Configs) Validate() error {
+	names := make(map[string]bool)
+
+	for _, c := range cs {
+		err := c.Validate()
+		if err != nil {
+			return err
+		}
+
+		if names[c.Name] {
+			return fmt.Errorf("The collection %s is defined more than once", c.Name)
+		}
+
+		names[c.Name] = true
+	}
+
+	return nil
+}
+
+// MarshalJSON renders the collections in the collections.json format
+func (cs Configs) MarshalJSON() ([]byte, error) {
+	err := cs.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]configJSON, len(cs))
+
+	for i, c := range cs {
+		out[i] = configJSON{
+			Name:              c.Name,
+			Policy:            c.Policy(),
+			RequiredPeerCount: c.RequiredPeerCount,
+			MaxPeerCount:      c.MaxPeerCount,
+			BlockToLive:       c.BlockToLive,
+			MemberOnlyRead:    c.MemberOnlyRead,
+			MemberOnlyWrite:   c.MemberOnlyWrite,
+		}
+
+		if c.EndorsementPolicy != "" {
+			out[i].EndorsementPolicy = &endorsementPolicyJSON{SignaturePolicy: c.EndorsementPolicy}
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// Shared returns a collection readable and writable by members of all the given organizations
+func Shared(name string, members ...string) Config {
+	return Config{
+		Name:            name,
+		Members:         members,
+		MaxPeerCount:    len(members),
+		MemberOnlyRead:  true,
+		MemberOnlyWrite: true,
+	}
+}
+
+// Bilateral returns a collection shared by exactly two organizations, named after them in a stable order
+func Bilateral(mspid1 string, mspid2 string) Config {
+	members := []string{mspid1, mspid2}
+	sort.Strings(members)
+
+	return Shared("bilateral_"+members[0]+"_"+members[1], members...)
+}
+
+// Default returns the collections the chaincode is deployed with
+func Default() Configs {
+	return Configs{
+		Shared("CryptoMotionCoinShared", "Org1MSP", "Org2MSP"),
+		Bilateral("Org1MSP", "Org2MSP"),
+	}
+}
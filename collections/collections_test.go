@@ -0,0 +1,55 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	assert.Equal(t, "OR('Org1MSP.member')", Shared("c", "Org1MSP").Policy(), "should grant the member role of a single organization")
+	assert.Equal(t, "OR('Org1MSP.member','Org2MSP.member')", Shared("c", "Org1MSP", "Org2MSP").Policy(), "should grant the member role of every organization")
+}
+
+func TestBilateral(t *testing.T) {
+	assert.Equal(t, Bilateral("Org1MSP", "Org2MSP"), Bilateral("Org2MSP", "Org1MSP"), "should not depend on the order of the organizations")
+	assert.Equal(t, "bilateral_Org1MSP_Org2MSP", Bilateral("Org2MSP", "Org1MSP").Name)
+}
+
+func TestValidate(t *testing.T) {
+	assert.EqualError(t, Config{}.Validate(), "The collection name must be specified")
+	assert.EqualError(t, Shared("_implicit_org_Org1MSP", "Org1MSP").Validate(), "The collection name _implicit_org_Org1MSP is reserved for implicit collections")
+	assert.EqualError(t, Shared("c").Validate(), "The collection c must have at least one member")
+	assert.EqualError(t, Shared("c", "").Validate(), "The collection c has a blank member")
+	assert.EqualError(t, Config{Name: "c", Members: []string{"Org1MSP"}, RequiredPeerCount: -1}.Validate(), "The collection c must not have a negative requiredPeerCount")
+	assert.EqualError(t, Config{Name: "c", Members: []string{"Org1MSP"}, RequiredPeerCount: 2, MaxPeerCount: 1}.Validate(), "The collection c must have a maxPeerCount of at least 2")
+	assert.EqualError(t, Configs{Shared("c", "Org1MSP"), Shared("c", "Org2MSP")}.Validate(), "The collection c is defined more than once")
+	assert.Nil(t, Default().Validate(), "should accept the default collections")
+}
+
+func TestMarshalJSON(t *testing.T) {
+	configs := Configs{Shared("c", "Org1MSP", "Org2MSP")}
+	configs[0].BlockToLive = 100
+	configs[0].EndorsementPolicy = "AND('Org1MSP.peer','Org2MSP.peer')"
+
+	bytes, err := json.Marshal(configs)
+	assert.Nil(t, err, "should not return error for valid collections")
+	assert.JSONEq(t, `[{
+		"name": "c",
+		"policy": "OR('Org1MSP.member','Org2MSP.member')",
+		"requiredPeerCount": 0,
+		"maxPeerCount": 2,
+		"blockToLive": 100,
+		"memberOnlyRead": true,
+		"memberOnlyWrite": true,
+		"endorsementPolicy": {"signaturePolicy": "AND('Org1MSP.peer','Org2MSP.peer')"}
+	}]`, string(bytes))
+
+	_, err = json.Marshal(Configs{Config{}})
+	assert.Error(t, err, "should error for invalid collections")
+}
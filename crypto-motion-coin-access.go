@@ -0,0 +1,126 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// roleAttribute is the X.509 certificate attribute holding the CryptoMotionCoin role of a client
+const roleAttribute = "cmc.role"
+
+// The roles a client may hold. Admins satisfy every policy
+const (
+	roleIssuer   = "issuer"
+	roleOperator = "operator"
+	roleAuditor  = "auditor"
+	roleAdmin    = "admin"
+)
+
+// transactionPolicies lists the roles allowed to submit each transaction checked by authorize, which refuses transactions
+// without an entry. Transactions that never call authorize are open to every client: they only read balances,
+// allowances, unspent outputs, holds, public records or hashes, or return the account of the client
+var transactionPolicies = map[string][]string{
+	"CreateCryptoMotionCoin":               {roleIssuer},
+	"ReadCryptoMotionCoin":                 {roleIssuer, roleOperator, roleAuditor},
+	"UpdateCryptoMotionCoin":               {roleIssuer, roleOperator},
+	"DeleteCryptoMotionCoin":               {roleIssuer},
+	"ReadCryptoMotionCoinView":             {roleIssuer, roleOperator, roleAuditor},
+	"ListCryptoMotionCoins":                {roleIssuer, roleOperator, roleAuditor},
+	"QueryCryptoMotionCoins":               {roleIssuer, roleOperator, roleAuditor},
+	"QueryCryptoMotionCoinsByOwner":        {roleIssuer, roleOperator, roleAuditor},
+	"QueryCryptoMotionCoinsByStatus":       {roleIssuer, roleOperator, roleAuditor},
+	"GetCryptoMotionCoinHistory":           {roleIssuer, roleOperator, roleAuditor},
+	"AgreeToSellCryptoMotionCoin":          {roleIssuer, roleOperator},
+	"AgreeToBuyCryptoMotionCoin":           {roleIssuer, roleOperator},
+	"TransferCryptoMotionCoin":             {roleIssuer, roleOperator},
+	"BatchCryptoMotionCoins":               {roleIssuer, roleOperator},
+	"MigrateCryptoMotionCoins":             {roleAdmin},
+	"PurgeCryptoMotionCoin":                {roleAdmin},
+	"SetCryptoMotionCoinEndorsementPolicy": {roleIssuer},
+	"GetCryptoMotionCoinEndorsementPolicy": {roleIssuer, roleOperator, roleAuditor},
+	"Mint":                                 {roleIssuer},
+	"Burn":                                 {roleIssuer, roleOperator},
+	"Transfer":                             {roleIssuer, roleOperator},
+	"Approve":                              {roleIssuer, roleOperator},
+	"TransferFrom":                         {roleIssuer, roleOperator},
+	"MintUTXO":                             {roleIssuer},
+	"TransferUTXO":                         {roleIssuer, roleOperator},
+	"HoldCryptoMotionCoin":                 {roleIssuer, roleOperator},
+	"ReleaseHold":                          {roleIssuer, roleOperator},
+	"ExecuteHold":                          {roleIssuer, roleOperator},
+	"DeliverHold":                          {roleIssuer, roleOperator},
+	"ExpireHold":                           {roleIssuer, roleOperator},
+}
+
+// assertCreator checks the client created a CryptoMotionCoin before it is modified. Admins may modify any CryptoMotionCoin,
+// including those stored before creators were recorded
+func assertCreator(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string, cryptoMotionCoin *CryptoMotionCoin) error {
+	role, err := getClientRole(ctx)
+	if err != nil {
+		return err
+	} else if role == roleAdmin {
+		return nil
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+	}
+
+	if cryptoMotionCoin.CreatorID == "" || cryptoMotionCoin.CreatorID != clientID {
+		return cmcerrors.Unauthorizedf("Access denied. Only the creator of asset %s or an admin may modify it", cryptoMotionCoinID)
+	}
+
+	return nil
+}
+
+// getClientRole returns the role of the client, or a blank string when its certificate has no role attribute
+func getClientRole(ctx contractapi.TransactionContextInterface) (string, error) {
+	role, _, err := ctx.GetClientIdentity().GetAttributeValue(roleAttribute)
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not read client attributes. %w", err)
+	}
+
+	return role, nil
+}
+
+func describeRoles(roles []string) string {
+	if len(roles) == 1 {
+		return fmt.Sprintf("The %s role is", roles[0])
+	}
+
+	return fmt.Sprintf("One of the %s or %s roles is", strings.Join(roles[:len(roles)-1], ", "), roles[len(roles)-1])
+}
+
+// authorize checks the role of the client against the policy of a transaction
+func authorize(ctx contractapi.TransactionContextInterface, transaction string) error {
+	roles, exists := transactionPolicies[transaction]
+	if !exists {
+		return cmcerrors.Unauthorizedf("Access denied. No policy is defined for transaction %s", transaction)
+	}
+
+	role, err := getClientRole(ctx)
+	if err != nil {
+		return err
+	}
+
+	if role == roleAdmin {
+		return nil
+	}
+
+	for _, allowed := range roles {
+		if role == allowed {
+			return nil
+		}
+	}
+
+	return cmcerrors.Unauthorizedf("Access denied. %s required for %s", describeRoles(roles), transaction)
+}
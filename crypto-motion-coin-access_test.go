@@ -0,0 +1,131 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+// openTransactions are the transactions open to every client since they only read balances, allowances, unspent
+// outputs, holds, public records or hashes, or return the account of the client
+var openTransactions = []string{
+	"CryptoMotionCoinExists", "VerifyCryptoMotionCoin", "VerifyCryptoMotionCoinBytes", "ReadPublicCryptoMotionCoin",
+	"ClientAccountID", "BalanceOf", "TotalSupply", "Allowance", "UTXOsOf", "ReadHold",
+}
+
+func TestTransactionPolicies(t *testing.T) {
+	contractType := reflect.TypeOf(new(CryptoMotionCoinContract))
+
+	for transaction, roles := range transactionPolicies {
+		_, exists := contractType.MethodByName(transaction)
+		assert.True(t, exists, "should only define policies for transactions of the contract: %s", transaction)
+		assert.NotEmpty(t, roles, "should allow at least one role for %s", transaction)
+	}
+
+	baseType := reflect.TypeOf(new(contractapi.Contract))
+
+	for i := 0; i < contractType.NumMethod(); i++ {
+		transaction := contractType.Method(i).Name
+
+		if _, inherited := baseType.MethodByName(transaction); inherited {
+			continue
+		}
+
+		_, restricted := transactionPolicies[transaction]
+		assert.True(t, restricted || containsString(openTransactions, transaction), "should define a policy for %s or list it as open", transaction)
+	}
+}
+
+func TestRestrictedTokenDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	auditor := newScenarioIdentity(t, "Org1MSP", "auditor1", roleAuditor)
+
+	for _, args := range [][]string{
+		{"Burn", "1"},
+		{"Transfer", "recipient", "1"},
+		{"Approve", "spender", "1"},
+		{"TransferFrom", "owner", "recipient", "1"},
+		{"TransferUTXO", `["utxo1"]`, `[{"owner":"recipient","amount":1}]`},
+		{"HoldCryptoMotionCoin", "hold1", "", "1", "payee", "notary", "1h"},
+		{"ReleaseHold", "hold1"},
+		{"ExecuteHold", "hold1"},
+		{"DeliverHold", "hold1"},
+		{"ExpireHold", "hold1"},
+	} {
+		response := ledger.Invoke(chaincode, auditor, nil, args...)
+		assert.Equal(t, "[UNAUTHORIZED] Access denied. One of the issuer or operator roles is required for "+args[0], response.Message, "should not let auditors move coins")
+	}
+
+	response := ledger.Invoke(chaincode, auditor, nil, "BalanceOf", "owner")
+	assert.Equal(t, "", response.Message, "should let every client read balances")
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx, _ := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	assert.Nil(t, authorize(ctx, "CreateCryptoMotionCoin"), "should allow a role listed in the policy")
+	assert.EqualError(t, authorize(ctx, "Unknown"), "[UNAUTHORIZED] Access denied. No policy is defined for transaction Unknown", "should deny transactions without a policy")
+
+	setClientAttribute(ctx, roleAttribute, roleAuditor)
+	assert.Nil(t, authorize(ctx, "ReadCryptoMotionCoin"), "should allow auditors to read")
+	assert.EqualError(t, authorize(ctx, "CreateCryptoMotionCoin"), "[UNAUTHORIZED] Access denied. The issuer role is required for CreateCryptoMotionCoin", "should deny a role not listed in the policy")
+	assert.EqualError(t, authorize(ctx, "UpdateCryptoMotionCoin"), "[UNAUTHORIZED] Access denied. One of the issuer or operator roles is required for UpdateCryptoMotionCoin")
+
+	setClientAttribute(ctx, roleAttribute, roleAdmin)
+	assert.Nil(t, authorize(ctx, "TransferCryptoMotionCoin"), "should allow admins to submit every transaction")
+
+	delete(ctx.GetClientIdentity().(*MockClientIdentity).attributes, roleAttribute)
+	assert.EqualError(t, authorize(ctx, "ReadCryptoMotionCoin"), "[UNAUTHORIZED] Access denied. One of the issuer, operator or auditor roles is required for ReadCryptoMotionCoin", "should deny clients without a role")
+
+	err := c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. The issuer role is required for DeleteCryptoMotionCoin", "should check the policy before touching the ledger")
+
+	_, err = c.ClientAccountID(ctx)
+	assert.Nil(t, err, "should not restrict transactions without a policy")
+}
+
+func TestAssertCreator(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stub.On("GetPrivateDataHash", mock.AnythingOfType("string"), "legacykey").Return([]byte("some hash value"), nil)
+	stub.On("GetPrivateData", mock.AnythingOfType("string"), "legacykey").Return([]byte(legacyJSON), nil)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user1","amount":75,"denomination":"CMC"}`)
+	setClientID(ctx, "x509::CN=user2")
+
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. Only the creator of asset cryptoMotionCoinkey or an admin may modify it", "should deny updates from another identity")
+
+	err = c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. Only the creator of asset cryptoMotionCoinkey or an admin may modify it", "should deny deletes from another identity")
+	stub.AssertNotCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", "cryptoMotionCoinkey")
+
+	setClientID(ctx, "x509::CN=user1")
+
+	err = c.UpdateCryptoMotionCoin(ctx, "legacykey")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. Only the creator of asset legacykey or an admin may modify it", "should deny updates of assets without a recorded creator")
+
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should allow the creator to update")
+
+	setClientID(ctx, "x509::CN=user2")
+	setClientAttribute(ctx, roleAttribute, roleAdmin)
+
+	err = c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should allow admins to delete assets of another identity")
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", "cryptoMotionCoinkey")
+}
@@ -0,0 +1,252 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/events"
+)
+
+// batchTransientKey is the transient data key holding the JSON array of operations of a batch
+const batchTransientKey = "batch"
+
+// maxBatchSize caps the operations of a batch so a single transaction stays within the limits of the peers
+const maxBatchSize = 1000
+
+// CryptoMotionCoinOperation is one change of a batch. The asset is required to create or update a CryptoMotionCoin and ignored
+// when deleting one
+type CryptoMotionCoinOperation struct {
+	Action CryptoMotionCoinAction `json:"action"`
+	ID     string                 `json:"id"`
+	Asset  *CryptoMotionCoin      `json:"asset,omitempty" metadata:"asset,optional"`
+}
+
+// CryptoMotionCoinBatchResult reports the outcome of one operation of a batch
+type CryptoMotionCoinBatchResult struct {
+	Index     int                    `json:"index"`
+	ID        string                 `json:"id"`
+	Action    CryptoMotionCoinAction `json:"action"`
+	ValueHash string                 `json:"valueHash,omitempty" metadata:"valueHash,optional"`
+}
+
+// CryptoMotionCoinBatchReport lists the result of every operation of an applied batch
+type CryptoMotionCoinBatchReport struct {
+	Results []CryptoMotionCoinBatchResult `json:"results"`
+}
+
+var batchTransactions = map[CryptoMotionCoinAction]string{
+	ActionCreate: "CreateCryptoMotionCoin",
+	ActionUpdate: "UpdateCryptoMotionCoin",
+	ActionDelete: "DeleteCryptoMotionCoin",
+}
+
+func getTransientBatch(ctx contractapi.TransactionContextInterface) ([]CryptoMotionCoinOperation, error) {
+	transientData, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read transient data. %w", err)
+	}
+
+	document, exists := transientData[batchTransientKey]
+	if len(transientData) == 0 || !exists {
+		return nil, cmcerrors.InvalidInputf("The %s key was not specified in transient data. Please try again", batchTransientKey)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	var operations []CryptoMotionCoinOperation
+
+	err = decoder.Decode(&operations)
+	if err != nil {
+		return nil, cmcerrors.InvalidInputf("Could not unmarshal transient data to type []CryptoMotionCoinOperation. %w", err)
+	}
+
+	if len(operations) == 0 || len(operations) > maxBatchSize {
+		return nil, cmcerrors.InvalidInputf("The batch must contain between 1 and %d operations", maxBatchSize)
+	}
+
+	return operations, nil
+}
+
+// checkCryptoMotionCoinOperation applies the checks of the single transaction matching an operation without writing anything.
+// It completes the asset of a create or update and returns the existing CryptoMotionCoin of an update or delete
+func (c *CryptoMotionCoinContract) checkCryptoMotionCoinOperation(ctx contractapi.TransactionContextInterface, operation *CryptoMotionCoinOperation) (*CryptoMotionCoin, error) {
+	transaction, exists := batchTransactions[operation.Action]
+	if !exists {
+		return nil, cmcerrors.InvalidInputf("The action %s is not valid. It must be one of CREATE, UPDATE or DELETE", operation.Action)
+	}
+
+	err := authorize(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	if operation.Action != ActionDelete && operation.Asset == nil {
+		return nil, cmcerrors.InvalidInputf("The asset of a %s operation must be specified", operation.Action)
+	}
+
+	if operation.Action == ActionCreate {
+		exists, err := c.CryptoMotionCoinExists(ctx, operation.ID)
+		if err != nil {
+			return nil, err
+		} else if exists {
+			return nil, cmcerrors.AlreadyExistsf("The asset %s already exists", operation.ID)
+		}
+
+		return nil, prepareCreatedCryptoMotionCoin(ctx, operation.Asset)
+	}
+
+	existing, err := c.ReadCryptoMotionCoin(ctx, operation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = assertCreator(ctx, operation.ID, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = checkNotHeld(ctx, collectionName, operation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if operation.Action == ActionUpdate {
+		err = prepareUpdatedCryptoMotionCoin(ctx, existing, operation.Asset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return existing, nil
+}
+
+// newBatchError returns the error of a batch with invalid operations. It has the code of the first invalid operation and
+// lists the errors of all of them so the client can correct the batch at once
+func newBatchError(failures []error) error {
+	messages := make([]string, len(failures))
+	for i, failure := range failures {
+		messages[i] = failure.Error()
+	}
+
+	code := cmcerrors.CodeOf(failures[0])
+	if code == "" {
+		code = cmcerrors.InvalidInput
+	}
+
+	return &cmcerrors.Error{Code: code, Err: fmt.Errorf("The batch was not applied. %s", strings.Join(messages, ". "))}
+}
+
+// applyCryptoMotionCoinOperation writes an operation that passed its checks and returns the event it would have emitted on its own
+func applyCryptoMotionCoinOperation(ctx contractapi.TransactionContextInterface, collectionName string, operation *CryptoMotionCoinOperation, existing *CryptoMotionCoin) (events.BatchEntry, error) {
+	switch operation.Action {
+	case ActionCreate:
+		bytes, err := putCryptoMotionCoin(ctx, collectionName, operation.ID, operation.Asset)
+		if err != nil {
+			return events.BatchEntry{}, err
+		}
+
+		err = setCryptoMotionCoinEndorsementPolicy(ctx, collectionName, operation.ID, []string{operation.Asset.IssuerMSP}, false)
+		if err != nil {
+			return events.BatchEntry{}, err
+		}
+
+		return events.BatchEntry{Name: events.CreatedName, Payload: events.NewPayload(operation.ID, collectionName, getAppraisalHash(bytes))}, nil
+	case ActionUpdate:
+		bytes, err := replaceCryptoMotionCoin(ctx, collectionName, operation.ID, existing, operation.Asset)
+		if err != nil {
+			return events.BatchEntry{}, err
+		}
+
+		return events.BatchEntry{Name: events.UpdatedName, Payload: events.NewPayload(operation.ID, collectionName, getAppraisalHash(bytes))}, nil
+	default:
+		err := removeCryptoMotionCoin(ctx, collectionName, operation.ID, existing)
+		if err != nil {
+			return events.BatchEntry{}, err
+		}
+
+		return events.BatchEntry{Name: events.DeletedName, Payload: events.NewPayload(operation.ID, collectionName, "")}, nil
+	}
+}
+
+// BatchCryptoMotionCoins creates, updates and deletes CryptoMotionCoins in a single transaction. The operations are read from the
+// batch key of the transient data and each one is subject to the same checks as the matching single transaction. Every operation
+// is checked before any is written, so either all of them are applied or the transaction fails with the errors of the invalid
+// operations. An asset may only appear once per batch. Batches create private CryptoMotionCoins only and emit a single batch event
+func (c *CryptoMotionCoinContract) BatchCryptoMotionCoins(ctx contractapi.TransactionContextInterface) (*CryptoMotionCoinBatchReport, error) {
+	err := authorize(ctx, "BatchCryptoMotionCoins")
+	if err != nil {
+		return nil, err
+	}
+
+	operations, err := getTransientBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CryptoMotionCoinBatchReport{Results: make([]CryptoMotionCoinBatchResult, len(operations))}
+	existing := make([]*CryptoMotionCoin, len(operations))
+	seen := make(map[string]bool)
+	failures := []error{}
+
+	for i := range operations {
+		operation := &operations[i]
+		report.Results[i] = CryptoMotionCoinBatchResult{Index: i, ID: operation.ID, Action: operation.Action}
+
+		if operation.ID == "" {
+			err = cmcerrors.InvalidInputf("The asset ID must be specified")
+		} else if seen[operation.ID] {
+			err = cmcerrors.InvalidInputf("The asset %s appears more than once in the batch", operation.ID)
+		} else {
+			existing[i], err = c.checkCryptoMotionCoinOperation(ctx, operation)
+		}
+
+		seen[operation.ID] = true
+
+		if err != nil {
+			failures = append(failures, fmt.Errorf("Operation %d: %w", i, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, newBatchError(failures)
+	}
+
+	batch := events.Batch{Version: events.Version, Entries: []events.BatchEntry{}}
+
+	for i := range operations {
+		entry, err := applyCryptoMotionCoinOperation(ctx, collectionName, &operations[i], existing[i])
+		if err != nil {
+			return nil, err
+		}
+
+		report.Results[i].ValueHash = entry.ValueHash
+		batch.Entries = append(batch.Entries, entry)
+	}
+
+	err = emitCryptoMotionCoinEvent(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,183 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/collections"
+	"newprogmodelgoprivatecontract/events"
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+func TestGetTransientBatch(t *testing.T) {
+	var err error
+
+	ctx, _ := configureStub()
+
+	_, err = getTransientBatch(ctx)
+	assert.EqualError(t, err, "[INVALID_INPUT] The batch key was not specified in transient data. Please try again", "should error when no batch is provided")
+
+	transient[batchTransientKey] = []byte(`[{"action":"CREATE","id":"a","extra":true}]`)
+	_, err = getTransientBatch(ctx)
+	assert.EqualError(t, err, "[INVALID_INPUT] Could not unmarshal transient data to type []CryptoMotionCoinOperation. json: unknown field \"extra\"", "should error when an operation has unknown fields")
+
+	transient[batchTransientKey] = []byte(`[]`)
+	_, err = getTransientBatch(ctx)
+	assert.EqualError(t, err, "[INVALID_INPUT] The batch must contain between 1 and 1000 operations", "should error when the batch is empty")
+}
+
+func TestBatchCryptoMotionCoinsRejected(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[batchTransientKey] = []byte(`[
+		{"action":"CREATE","id":"missingkey","asset":{"owner":"user1","amount":5,"denomination":"CMC"}},
+		{"action":"CREATE","id":"existingkey","asset":{"owner":"user1","amount":5,"denomination":"CMC"}},
+		{"action":"UPDATE","id":"missingkey","asset":{"owner":"user1","amount":5,"denomination":"CMC"}},
+		{"action":"UPDATE","id":"cryptoMotionCoinkey"},
+		{"action":"DELETE","id":"statebad"},
+		{"action":"RENAME","id":"publickey"},
+		{"action":"CREATE","id":"","asset":{"owner":"","amount":0,"denomination":"CMC"}}
+	]`)
+
+	_, err = c.BatchCryptoMotionCoins(ctx)
+	assert.EqualError(t, err, "[ALREADY_EXISTS] The batch was not applied. "+
+		"Operation 1: [ALREADY_EXISTS] The asset existingkey already exists. "+
+		"Operation 2: [INVALID_INPUT] The asset missingkey appears more than once in the batch. "+
+		"Operation 3: [INVALID_INPUT] The asset of a UPDATE operation must be specified. "+
+		"Operation 4: [LEDGER_ERROR] Could not read from world state. "+getStateError+". "+
+		"Operation 5: [INVALID_INPUT] The action RENAME is not valid. It must be one of CREATE, UPDATE or DELETE. "+
+		"Operation 6: [INVALID_INPUT] The asset ID must be specified", "should fail with the errors of every invalid operation")
+	assert.True(t, errors.Is(err, cmcerrors.AlreadyExists), "should have the code of the first invalid operation")
+	stub.AssertNotCalled(t, "PutPrivateData", mock.Anything, mock.Anything, mock.Anything)
+	stub.AssertNotCalled(t, "SetEvent", mock.Anything, mock.Anything)
+
+	setClientAttribute(ctx, roleAttribute, roleOperator)
+	transient[batchTransientKey] = []byte(`[{"action":"DELETE","id":"cryptoMotionCoinkey"}]`)
+
+	_, err = c.BatchCryptoMotionCoins(ctx)
+	assert.EqualError(t, err, "[UNAUTHORIZED] The batch was not applied. Operation 0: [UNAUTHORIZED] Access denied. The issuer role is required for DeleteCryptoMotionCoin", "should apply the policy of the matching transaction to every operation")
+
+	setClientAttribute(ctx, roleAttribute, roleAuditor)
+	_, err = c.BatchCryptoMotionCoins(ctx)
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. One of the issuer or operator roles is required for BatchCryptoMotionCoins", "should error when the client may not submit batches")
+}
+
+func TestBatchCryptoMotionCoinsExpiredHold(t *testing.T) {
+	expiredHold := &CryptoMotionCoinHold{ID: "expiredhold", Payer: clientAccount, Payee: "Org1MSP:x509::CN=user2", PayeeID: "x509::CN=user2", Notary: notaryAccount, CryptoMotionCoinID: "heldkey", Collection: "_implicit_org_Org1MSP", Deadline: txTime.Add(-time.Hour), Status: HoldStatusHeld}
+	ctx, stub := configureHoldStub(expiredHold)
+	c := new(CryptoMotionCoinContract)
+
+	stored, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
+	assetHoldKey, _ := stub.CreateCompositeKey(assetHoldObjectType, []string{"_implicit_org_Org1MSP", "heldkey"})
+	stub.On("GetState", assetHoldKey).Return([]byte("expiredhold"), nil)
+	stub.On("GetPrivateDataHash", "_implicit_org_Org1MSP", "heldkey").Return([]byte("some hash value"), nil)
+	stub.On("GetPrivateData", "_implicit_org_Org1MSP", "heldkey").Return(stored, nil)
+
+	transient[batchTransientKey] = []byte(`[
+		{"action":"DELETE","id":"heldkey"},
+		{"action":"CREATE","id":"existingkey","asset":{"owner":"user1","amount":5,"denomination":"CMC"}}
+	]`)
+
+	_, err := c.BatchCryptoMotionCoins(ctx)
+	assert.EqualError(t, err, "[ALREADY_EXISTS] The batch was not applied. Operation 1: [ALREADY_EXISTS] The asset existingkey already exists", "should accept an asset whose hold has expired")
+	stub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+	stub.AssertNotCalled(t, "DelState", mock.Anything)
+}
+
+func TestBatchCryptoMotionCoinsApplied(t *testing.T) {
+	var report *CryptoMotionCoinBatchReport
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stored, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
+	stub.On("GetPrivateDataHash", mock.AnythingOfType("string"), "deletekey").Return([]byte("some hash value"), nil)
+	stub.On("GetPrivateData", mock.AnythingOfType("string"), "deletekey").Return(stored, nil)
+	stub.On("GetState", publicKey(stub, "_implicit_org_Org1MSP", "deletekey")).Return([]byte(nil), nil)
+	configureHistoryHeads(stub, "deletekey")
+	configureAssetHolds(stub, "deletekey")
+
+	transient[batchTransientKey] = []byte(`[
+		{"action":"CREATE","id":"missingkey","asset":{"owner":"user1","amount":5,"denomination":"CMC"}},
+		{"action":"UPDATE","id":"cryptoMotionCoinkey","asset":{"owner":"user3","amount":75,"denomination":"CMC"}},
+		{"action":"DELETE","id":"deletekey"}
+	]`)
+
+	report, err = c.BatchCryptoMotionCoins(ctx)
+	assert.Nil(t, err, "should not return error when every operation is valid")
+
+	created := &CryptoMotionCoin{Owner: "user1", Amount: 5, Denomination: "CMC", IssuerMSP: "Org1MSP", CreatorID: "x509::CN=user1", Status: StatusActive, CreatedAt: txTime, UpdatedAt: txTime}
+	createdBytes, _ := encodeCryptoMotionCoin(created)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "missingkey", createdBytes)
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", "deletekey")
+
+	updated := newTestCryptoMotionCoin()
+	updated.Owner = "user3"
+	updated.Amount = 75
+	updated.UpdatedAt = txTime
+	updatedBytes, _ := encodeCryptoMotionCoin(updated)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "cryptoMotionCoinkey", updatedBytes)
+
+	assert.Equal(t, []CryptoMotionCoinBatchResult{
+		{Index: 0, ID: "missingkey", Action: ActionCreate, ValueHash: getAppraisalHash(createdBytes)},
+		{Index: 1, ID: "cryptoMotionCoinkey", Action: ActionUpdate, ValueHash: getAppraisalHash(updatedBytes)},
+		{Index: 2, ID: "deletekey", Action: ActionDelete},
+	}, report.Results)
+
+	batch, _ := json.Marshal(events.Batch{Version: events.Version, Entries: []events.BatchEntry{
+		{Name: events.CreatedName, Payload: events.NewPayload("missingkey", "_implicit_org_Org1MSP", getAppraisalHash(createdBytes))},
+		{Name: events.UpdatedName, Payload: events.NewPayload("cryptoMotionCoinkey", "_implicit_org_Org1MSP", getAppraisalHash(updatedBytes))},
+		{Name: events.DeletedName, Payload: events.NewPayload("deletekey", "_implicit_org_Org1MSP", "")},
+	}})
+	stub.AssertCalled(t, "SetEvent", events.BatchName, batch)
+	stub.AssertNumberOfCalls(t, "SetEvent", 1)
+}
+
+func TestBatchDispatch(t *testing.T) {
+	var report CryptoMotionCoinBatchReport
+
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	collectionName := collections.ImplicitPrefix + "Org1MSP"
+
+	rejected := map[string][]byte{batchTransientKey: []byte(`[
+		{"action":"CREATE","id":"coin1","asset":{"owner":"user1","amount":5,"denomination":"CMC"}},
+		{"action":"DELETE","id":"coin2"}
+	]`)}
+
+	response := ledger.Invoke(chaincode, issuer, rejected, "BatchCryptoMotionCoins")
+	assert.Equal(t, "[NOT_FOUND] The batch was not applied. Operation 1: [NOT_FOUND] The asset coin2 does not exist", response.Message, "should fail the transaction when an operation is not valid")
+	assert.Nil(t, ledger.PrivateData(collectionName, "coin1"), "should not commit the valid operations of a failed batch")
+
+	applied := map[string][]byte{batchTransientKey: []byte(`[{"action":"CREATE","id":"coin1","asset":{"owner":"user1","amount":5,"denomination":"CMC"}}]`)}
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, applied, "BatchCryptoMotionCoins")), &report))
+	require.Len(t, report.Results, 1)
+	assert.NotEmpty(t, report.Results[0].ValueHash, "should report the hash of the created asset")
+	assert.NotNil(t, ledger.PrivateData(collectionName, "coin1"))
+
+	var deletedReport CryptoMotionCoinBatchReport
+
+	deleted := map[string][]byte{batchTransientKey: []byte(`[{"action":"DELETE","id":"coin1"}]`)}
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, deleted, "BatchCryptoMotionCoins")), &deletedReport))
+	require.Len(t, deletedReport.Results, 1)
+	assert.Empty(t, deletedReport.Results[0].ValueHash, "should report deletions without a hash")
+	assert.Nil(t, ledger.PrivateData(collectionName, "coin1"))
+}
@@ -0,0 +1,166 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// canonicalJSON serializes a value to the canonical JSON form used for everything hashed on the ledger
+func canonicalJSON(value interface{}) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(value)
+	if err != nil {
+		return nil, cmcerrors.Internalf("Could not marshal value of type %T. %w", value, err)
+	}
+
+	return canonicalizeJSON(buffer.Bytes())
+}
+
+// canonicalizeJSON rewrites a JSON document with sorted object keys, normalized numbers,
+// no insignificant whitespace and no HTML escaping so that equal documents hash equally
+func canonicalizeJSON(document []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, cmcerrors.InvalidInputf("Could not parse JSON document. %w", err)
+	}
+
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, cmcerrors.InvalidInputf("Could not parse JSON document. Unexpected data after the top-level value")
+	}
+
+	buffer := new(bytes.Buffer)
+
+	err = writeCanonicalJSON(buffer, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func writeCanonicalJSON(buffer *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		buffer.WriteString(strconv.FormatBool(v))
+	case string:
+		return writeCanonicalString(buffer, v)
+	case json.Number:
+		number, err := normalizeNumber(v)
+		if err != nil {
+			return err
+		}
+
+		buffer.WriteString(number)
+	case []interface{}:
+		buffer.WriteByte('[')
+
+		for i, element := range v {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+
+			err := writeCanonicalJSON(buffer, element)
+			if err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		buffer.WriteByte('{')
+
+		for i, key := range keys {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+
+			err := writeCanonicalString(buffer, key)
+			if err != nil {
+				return err
+			}
+
+			buffer.WriteByte(':')
+
+			err = writeCanonicalJSON(buffer, v[key])
+			if err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteByte('}')
+	default:
+		return cmcerrors.InvalidInputf("Could not canonicalize JSON value of type %T", value)
+	}
+
+	return nil
+}
+
+func writeCanonicalString(buffer *bytes.Buffer, value string) error {
+	encoded := new(bytes.Buffer)
+
+	encoder := json.NewEncoder(encoded)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(value)
+	if err != nil {
+		return cmcerrors.Internalf("Could not marshal string %q. %w", value, err)
+	}
+
+	buffer.Write(bytes.TrimRight(encoded.Bytes(), "\n"))
+
+	return nil
+}
+
+// normalizeNumber renders integral numbers exactly without fraction or exponent, and
+// other numbers as the shortest decimal that round-trips through a float64
+func normalizeNumber(number json.Number) (string, error) {
+	float, err := strconv.ParseFloat(number.String(), 64)
+	if err != nil {
+		return "", cmcerrors.InvalidInputf("Could not parse JSON number %s", number)
+	}
+
+	rational, ok := new(big.Rat).SetString(number.String())
+	if !ok {
+		return "", cmcerrors.InvalidInputf("Could not parse JSON number %s", number)
+	}
+
+	if rational.IsInt() {
+		return rational.Num().String(), nil
+	}
+
+	if abs := math.Abs(float); abs < 1e-6 || abs >= 1e21 {
+		return strconv.FormatFloat(float, 'e', -1, 64), nil
+	}
+
+	return strconv.FormatFloat(float, 'f', -1, 64), nil
+}
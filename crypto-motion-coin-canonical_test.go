@@ -0,0 +1,49 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	var canonical []byte
+	var err error
+
+	canonical, err = canonicalizeJSON([]byte(" { \"b\" : [ 1 , 2.50, 1e2, -0 ], \"a\" : { \"z\" : null, \"y\" : true } } "))
+	assert.Nil(t, err, "should not return error for a valid document")
+	assert.Equal(t, `{"a":{"y":true,"z":null},"b":[1,2.5,100,0]}`, string(canonical), "should sort keys, normalize numbers and strip whitespace")
+
+	canonical, err = canonicalizeJSON([]byte(`{"value":"<a&b>","unicode":"é"}`))
+	assert.Nil(t, err, "should not return error for a document with escapable characters")
+	assert.Equal(t, `{"unicode":"é","value":"<a&b>"}`, string(canonical), "should not escape HTML characters or non-ASCII characters")
+
+	canonical, err = canonicalizeJSON([]byte(`{"amount":9223372036854775807,"small":0.0000001,"big":1.5e22}`))
+	assert.Nil(t, err, "should not return error for large and small numbers")
+	assert.Equal(t, `{"amount":9223372036854775807,"big":15000000000000000000000,"small":1e-07}`, string(canonical), "should keep integers exact and use exponents for very small numbers")
+
+	_, err = canonicalizeJSON([]byte(`{"a":1} {"b":2}`))
+	assert.EqualError(t, err, "[INVALID_INPUT] Could not parse JSON document. Unexpected data after the top-level value", "should error when there is trailing data")
+
+	_, err = canonicalizeJSON([]byte(`{"a":`))
+	assert.Error(t, err, "should error for a truncated document")
+
+	_, err = canonicalizeJSON([]byte(`{"a":1e999}`))
+	assert.EqualError(t, err, "[INVALID_INPUT] Could not parse JSON number 1e999", "should error for numbers out of range")
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	cryptoMotionCoin := newTestCryptoMotionCoin()
+	cryptoMotionCoin.Metadata = map[string]string{"note": "<value>"}
+
+	canonical, err := canonicalJSON(cryptoMotionCoin)
+	assert.Nil(t, err, "should not return error for a CryptoMotionCoin")
+	assert.Equal(t, `{"amount":100,"createdAt":"2020-05-01T09:30:00Z","creatorID":"x509::CN=user1","denomination":"CMC","issuerMSP":"Org1MSP","metadata":{"note":"<value>"},"owner":"user1","status":"ACTIVE","updatedAt":"2020-05-01T09:30:00Z"}`, string(canonical), "should serialize with sorted keys and without HTML escaping")
+
+	_, err = canonicalJSON(make(chan int))
+	assert.EqualError(t, err, "[INTERNAL] Could not marshal value of type chan int. json: unsupported type: chan int", "should error when the value cannot be marshalled")
+}
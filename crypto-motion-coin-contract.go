@@ -5,14 +5,29 @@
 package main
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/collections"
+	"newprogmodelgoprivatecontract/events"
 )
 
+//go:generate go run ./cmd/collectionsgen -o collections.json
+
+// collectionTransientKey is the transient data key used to select a named collection for a transaction
+const collectionTransientKey = "collection"
+
+var collectionConfigs = collections.Default()
+
+// cryptoMotionCoinTransientKey is the transient data key holding the CryptoMotionCoin JSON document of a create or update
+const cryptoMotionCoinTransientKey = "cryptoMotionCoin"
+
 // CryptoMotionCoinContract contract for managing CRUD for CryptoMotionCoin
 type CryptoMotionCoinContract struct {
 	contractapi.Contract
@@ -21,12 +36,81 @@ type CryptoMotionCoinContract struct {
 func getCollectionName(ctx contractapi.TransactionContextInterface) (string, error) {
 	mspid, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return "", err
+		return "", cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+	}
+
+	transientData, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not read transient data. %w", err)
+	}
+
+	requested, exists := transientData[collectionTransientKey]
+	if !exists || len(requested) == 0 {
+		requested = []byte(activeConfig.defaultCollectionName(mspid))
+	}
+
+	if string(requested) == collections.ImplicitPrefix+mspid {
+		return traceCollection(ctx, collections.ImplicitPrefix+mspid), nil
+	}
+
+	config, exists := collectionConfigs.Lookup(string(requested))
+	if !exists {
+		return "", cmcerrors.InvalidInputf("The collection %s is not defined", requested)
+	} else if !config.HasMember(mspid) {
+		return "", cmcerrors.Unauthorizedf("The organization %s is not a member of the collection %s", mspid, requested)
+	}
+
+	return traceCollection(ctx, config.Name), nil
+}
+
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, cmcerrors.LedgerErrorf("Could not read transaction timestamp. %w", err)
 	}
 
-	collectionName := "_implicit_org_" + mspid
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC(), nil
+}
 
-	return collectionName, nil
+// createCompositeKey returns the composite key of an object. It fails when an attribute is not valid UTF-8 or contains
+// the characters reserved by composite keys
+func createCompositeKey(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not create %s key. %w", objectType, err)
+	}
+
+	return key, nil
+}
+
+// createCollectionKey returns the world state key of an object about the CryptoMotionCoin held in a collection. Such keys
+// start with the collection since organizations may each hold a CryptoMotionCoin of the same ID in their own collection
+func createCollectionKey(ctx contractapi.TransactionContextInterface, objectType string, collectionName string, cryptoMotionCoinID string, attributes ...string) (string, error) {
+	return createCompositeKey(ctx, objectType, append([]string{collectionName, cryptoMotionCoinID}, attributes...))
+}
+
+func getTransientCryptoMotionCoin(ctx contractapi.TransactionContextInterface) (*CryptoMotionCoin, error) {
+	transientData, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read transient data. %w", err)
+	}
+
+	document, exists := transientData[cryptoMotionCoinTransientKey]
+	if len(transientData) == 0 || !exists {
+		return nil, cmcerrors.InvalidInputf("The %s key was not specified in transient data. Please try again", cryptoMotionCoinTransientKey)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	cryptoMotionCoin := new(CryptoMotionCoin)
+
+	err = decoder.Decode(cryptoMotionCoin)
+	if err != nil {
+		return nil, cmcerrors.InvalidInputf("Could not unmarshal transient data to type CryptoMotionCoin. %w", err)
+	}
+
+	return cryptoMotionCoin, nil
 }
 
 // CryptoMotionCoinExists returns true when asset with given ID exists in private data collection
@@ -39,7 +123,7 @@ func (c *CryptoMotionCoinContract) CryptoMotionCoinExists(ctx contractapi.Transa
 	data, err := ctx.GetStub().GetPrivateDataHash(collectionName, cryptoMotionCoinID)
 
 	if err != nil {
-		return false, err
+		return false, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
 	}
 
 	return data != nil, nil
@@ -47,42 +131,138 @@ func (c *CryptoMotionCoinContract) CryptoMotionCoinExists(ctx contractapi.Transa
 
 // CreateCryptoMotionCoin creates a new instance of CryptoMotionCoin
 func (c *CryptoMotionCoinContract) CreateCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) error {
+	err := authorize(ctx, "CreateCryptoMotionCoin")
+	if err != nil {
+		return err
+	}
+
 	exists, err := c.CryptoMotionCoinExists(ctx, cryptoMotionCoinID)
 	if err != nil {
-		return fmt.Errorf("Could not read from world state. %s", err)
+		return err
 	} else if exists {
-		return fmt.Errorf("The asset %s already exists", cryptoMotionCoinID)
+		return cmcerrors.AlreadyExistsf("The asset %s already exists", cryptoMotionCoinID)
 	}
 
-	cryptoMotionCoin := new(CryptoMotionCoin)
+	cryptoMotionCoin, err := getTransientCryptoMotionCoin(ctx)
+	if err != nil {
+		return err
+	}
+
+	public, err := isPublicRequested(ctx)
+	if err != nil {
+		return err
+	}
+
+	collectionName, collectionNameErr := getCollectionName(ctx)
+	if collectionNameErr != nil {
+		return collectionNameErr
+	}
 
-	transientData, _ := ctx.GetStub().GetTransient()
+	if public {
+		existing, err := getPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+		if err != nil {
+			return err
+		} else if existing != nil {
+			return cmcerrors.AlreadyExistsf("The asset %s already exists", cryptoMotionCoinID)
+		}
+	}
 
-	privateValue, exists := transientData["privateValue"]
+	err = prepareCreatedCryptoMotionCoin(ctx, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
 
-	if len(transientData) == 0 || !exists {
-		return fmt.Errorf("The privateValue key was not specified in transient data. Please try again")
+	bytes, err := putCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID, cryptoMotionCoin)
+	if err != nil {
+		return err
 	}
 
-	cryptoMotionCoin.PrivateValue = string(privateValue)
+	err = emitCryptoMotionCoinEvent(ctx, events.Created{Payload: events.NewPayload(cryptoMotionCoinID, collectionName, getAppraisalHash(bytes))})
+	if err != nil {
+		return err
+	}
 
-	bytes, _ := json.Marshal(cryptoMotionCoin)
+	if public {
+		err = putPublicCryptoMotionCoin(ctx, collectionName, &CryptoMotionCoinPublic{
+			ID:            cryptoMotionCoinID,
+			OwnerMSP:      cryptoMotionCoin.IssuerMSP,
+			Status:        cryptoMotionCoin.Status,
+			AppraisalHash: getAppraisalHash(bytes),
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-	collectionName, collectionNameErr := getCollectionName(ctx)
-	if collectionNameErr != nil {
-		return collectionNameErr
+	return setCryptoMotionCoinEndorsementPolicy(ctx, collectionName, cryptoMotionCoinID, []string{cryptoMotionCoin.IssuerMSP}, public)
+}
+
+// prepareCreatedCryptoMotionCoin stamps a new CryptoMotionCoin with its issuer, creator and timestamps and validates it
+func prepareCreatedCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoin *CryptoMotionCoin) error {
+	mspid, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
 	}
 
-	return ctx.GetStub().PutPrivateData(collectionName, cryptoMotionCoinID, bytes)
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	creatorID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+	}
+
+	cryptoMotionCoin.IssuerMSP = mspid
+	cryptoMotionCoin.CreatorID = creatorID
+	cryptoMotionCoin.CreatedAt = now
+	cryptoMotionCoin.UpdatedAt = now
+
+	if cryptoMotionCoin.Status == "" {
+		cryptoMotionCoin.Status = StatusActive
+	}
+
+	return cryptoMotionCoin.Validate()
+}
+
+// putCryptoMotionCoin writes a new CryptoMotionCoin with its indexes and history and returns the stored value
+func putCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, cryptoMotionCoin *CryptoMotionCoin) ([]byte, error) {
+	bytes, err := encodeCryptoMotionCoin(cryptoMotionCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetStub().PutPrivateData(collectionName, cryptoMotionCoinID, bytes)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	err = putCryptoMotionCoinIndexes(ctx, collectionName, cryptoMotionCoinID, cryptoMotionCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordCryptoMotionCoinHistory(ctx, collectionName, cryptoMotionCoinID, ActionCreate, bytes, cryptoMotionCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes, nil
 }
 
 // ReadCryptoMotionCoin retrieves an instance of CryptoMotionCoin from the private data collection
 func (c *CryptoMotionCoinContract) ReadCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) (*CryptoMotionCoin, error) {
+	err := authorize(ctx, "ReadCryptoMotionCoin")
+	if err != nil {
+		return nil, err
+	}
+
 	exists, err := c.CryptoMotionCoinExists(ctx, cryptoMotionCoinID)
 	if err != nil {
-		return nil, fmt.Errorf("Could not read from world state. %s", err)
+		return nil, err
 	} else if !exists {
-		return nil, fmt.Errorf("The asset %s does not exist", cryptoMotionCoinID)
+		return nil, cmcerrors.NotFoundf("The asset %s does not exist", cryptoMotionCoinID)
 	}
 
 	collectionName, collectionNameErr := getCollectionName(ctx)
@@ -90,14 +270,17 @@ func (c *CryptoMotionCoinContract) ReadCryptoMotionCoin(ctx contractapi.Transact
 		return nil, collectionNameErr
 	}
 
-	bytes, _ := ctx.GetStub().GetPrivateData(collectionName, cryptoMotionCoinID)
-
-	cryptoMotionCoin := new(CryptoMotionCoin)
+	bytes, err := ctx.GetStub().GetPrivateData(collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return nil, cmcerrors.NotFoundf("The asset %s does not exist", cryptoMotionCoinID)
+	}
 
-	err = json.Unmarshal(bytes, cryptoMotionCoin)
+	cryptoMotionCoin, _, err := decodeCryptoMotionCoin(ctx, collectionName, bytes)
 
 	if err != nil {
-		return nil, fmt.Errorf("Could not unmarshal private data collection data to type CryptoMotionCoin")
+		return nil, cmcerrors.LedgerErrorf("Could not unmarshal private data collection data to type CryptoMotionCoin. %w", err)
 	}
 
 	return cryptoMotionCoin, nil
@@ -105,40 +288,120 @@ func (c *CryptoMotionCoinContract) ReadCryptoMotionCoin(ctx contractapi.Transact
 
 // UpdateCryptoMotionCoin retrieves an instance of CryptoMotionCoin from the private data collection and updates its value
 func (c *CryptoMotionCoinContract) UpdateCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) error {
-	exists, err := c.CryptoMotionCoinExists(ctx, cryptoMotionCoinID)
+	err := authorize(ctx, "UpdateCryptoMotionCoin")
 	if err != nil {
-		return fmt.Errorf("Could not read from world state. %s", err)
-	} else if !exists {
-		return fmt.Errorf("The asset %s does not exist", cryptoMotionCoinID)
+		return err
 	}
 
-	transientData, _ := ctx.GetStub().GetTransient()
-	newValue, exists := transientData["privateValue"]
-
-	if len(transientData) == 0 || !exists {
-		return fmt.Errorf("The privateValue key was not specified in transient data. Please try again")
+	existing, err := c.ReadCryptoMotionCoin(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return err
 	}
 
-	cryptoMotionCoin := new(CryptoMotionCoin)
-	cryptoMotionCoin.PrivateValue = string(newValue)
-
-	bytes, _ := json.Marshal(cryptoMotionCoin)
+	err = assertCreator(ctx, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
+	}
 
 	collectionName, collectionNameErr := getCollectionName(ctx)
 	if collectionNameErr != nil {
 		return collectionNameErr
 	}
 
-	return ctx.GetStub().PutPrivateData(collectionName, cryptoMotionCoinID, bytes)
+	err = assertNotHeld(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	cryptoMotionCoin, err := getTransientCryptoMotionCoin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = prepareUpdatedCryptoMotionCoin(ctx, existing, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := replaceCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID, existing, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	return emitCryptoMotionCoinEvent(ctx, events.Updated{Payload: events.NewPayload(cryptoMotionCoinID, collectionName, getAppraisalHash(bytes))})
+}
+
+// prepareUpdatedCryptoMotionCoin carries the issuer, creator and creation time of an existing CryptoMotionCoin over to its new
+// version, stamps the update time and validates it. The status is kept when the new version does not set one
+func prepareUpdatedCryptoMotionCoin(ctx contractapi.TransactionContextInterface, existing *CryptoMotionCoin, cryptoMotionCoin *CryptoMotionCoin) error {
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	cryptoMotionCoin.IssuerMSP = existing.IssuerMSP
+	cryptoMotionCoin.CreatorID = existing.CreatorID
+	cryptoMotionCoin.CreatedAt = existing.CreatedAt
+	cryptoMotionCoin.UpdatedAt = now
+
+	if cryptoMotionCoin.Status == "" {
+		cryptoMotionCoin.Status = existing.Status
+	}
+
+	return cryptoMotionCoin.Validate()
+}
+
+// replaceCryptoMotionCoin validates and writes a new version of a CryptoMotionCoin, keeping its indexes, history and public record
+// in line, and returns the stored value
+func replaceCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, existing *CryptoMotionCoin, cryptoMotionCoin *CryptoMotionCoin) ([]byte, error) {
+	err := cryptoMotionCoin.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := encodeCryptoMotionCoin(cryptoMotionCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetStub().PutPrivateData(collectionName, cryptoMotionCoinID, bytes)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	err = updateCryptoMotionCoinIndexes(ctx, collectionName, cryptoMotionCoinID, existing, cryptoMotionCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordCryptoMotionCoinHistory(ctx, collectionName, cryptoMotionCoinID, ActionUpdate, bytes, cryptoMotionCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = refreshPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID, cryptoMotionCoin, bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes, nil
 }
 
 // DeleteCryptoMotionCoin deletes an instance of CryptoMotionCoin from the private data collection
 func (c *CryptoMotionCoinContract) DeleteCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) error {
-	exists, err := c.CryptoMotionCoinExists(ctx, cryptoMotionCoinID)
+	err := authorize(ctx, "DeleteCryptoMotionCoin")
 	if err != nil {
-		return fmt.Errorf("Could not read from world state. %s", err)
-	} else if !exists {
-		return fmt.Errorf("The asset %s does not exist", cryptoMotionCoinID)
+		return err
+	}
+
+	existing, err := c.ReadCryptoMotionCoin(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = assertCreator(ctx, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
 	}
 
 	collectionName, collectionNameErr := getCollectionName(ctx)
@@ -146,20 +409,99 @@ func (c *CryptoMotionCoinContract) DeleteCryptoMotionCoin(ctx contractapi.Transa
 		return collectionNameErr
 	}
 
-	return ctx.GetStub().DelPrivateData(collectionName, cryptoMotionCoinID)
+	err = assertNotHeld(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = removeCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
+	}
+
+	return emitCryptoMotionCoinEvent(ctx, events.Deleted{Payload: events.NewPayload(cryptoMotionCoinID, collectionName, "")})
+}
+
+// removeCryptoMotionCoin deletes a CryptoMotionCoin with its indexes and public record and records the deletion in its history
+func removeCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, existing *CryptoMotionCoin) error {
+	err := ctx.GetStub().DelPrivateData(collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	err = delCryptoMotionCoinIndexes(ctx, collectionName, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
+	}
+
+	err = recordCryptoMotionCoinHistory(ctx, collectionName, cryptoMotionCoinID, ActionDelete, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	public, err := getPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil || public == nil {
+		return err
+	}
+
+	return delPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
 }
 
-// VerifyCryptoMotionCoin verifies the hash for an instance of CryptoMotionCoin from the private data collection matches the hash stored in the public ledger //FIXME check this
+// VerifyCryptoMotionCoin verifies the hash for an instance of CryptoMotionCoin from the private data collection matches the hash stored in the public ledger
 func (c *CryptoMotionCoinContract) VerifyCryptoMotionCoin(ctx contractapi.TransactionContextInterface, mspid string, cryptoMotionCoinID string, objectToVerify *CryptoMotionCoin) (bool, error) {
-	bytes, _ := json.Marshal(objectToVerify)
+	bytes, err := encodeCryptoMotionCoin(objectToVerify)
+	if err != nil {
+		return false, err
+	}
+
+	collectionName, err := getVerifiedCollectionName(ctx, mspid)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyPrivateDataHash(ctx, collectionName, cryptoMotionCoinID, bytes)
+}
+
+// VerifyCryptoMotionCoinBytes verifies the hash of a JSON document, after canonicalization and versioning, matches the hash stored in the public ledger
+func (c *CryptoMotionCoinContract) VerifyCryptoMotionCoinBytes(ctx contractapi.TransactionContextInterface, mspid string, cryptoMotionCoinID string, objectJSON string) (bool, error) {
+	bytes, err := encodeCryptoMotionCoinDocument([]byte(objectJSON))
+	if err != nil {
+		return false, err
+	}
+
+	collectionName, err := getVerifiedCollectionName(ctx, mspid)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyPrivateDataHash(ctx, collectionName, cryptoMotionCoinID, bytes)
+}
+
+// getVerifiedCollectionName returns the collection holding the hash to verify. A collection requested in the transient
+// data, or the default collection when no organization is given, is resolved like in every other transaction. Otherwise
+// the hash is read from the implicit collection of the organization
+func getVerifiedCollectionName(ctx contractapi.TransactionContextInterface, mspid string) (string, error) {
+	transientData, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not read transient data. %w", err)
+	}
+
+	if mspid == "" || len(transientData[collectionTransientKey]) > 0 {
+		return getCollectionName(ctx)
+	}
+
+	return collections.ImplicitPrefix + mspid, nil
+}
+
+func verifyPrivateDataHash(ctx contractapi.TransactionContextInterface, collectionName string, key string, bytes []byte) (bool, error) {
 	hashToVerify := sha256.New()
 	hashToVerify.Write(bytes)
 
-	pdHashBytes, err := ctx.GetStub().GetPrivateDataHash("_implicit_org_" + mspid, cryptoMotionCoinID)
+	pdHashBytes, err := ctx.GetStub().GetPrivateDataHash(collectionName, key)
 	if err != nil {
-		return false, err
+		return false, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
 	} else if len(pdHashBytes) == 0 {
-		return false, fmt.Errorf("No private data hash with the Key: %s", cryptoMotionCoinID)
+		return false, cmcerrors.NotFoundf("No private data hash with the Key: %s", key)
 	}
 
 	return hex.EncodeToString(hashToVerify.Sum(nil)) == hex.EncodeToString(pdHashBytes), nil
@@ -10,18 +10,30 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
 
 	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/collections"
+	"newprogmodelgoprivatecontract/ledgertest"
 )
 
 const getStateError = "private data get error"
 
 var transient map[string][]byte
 
+var createdTime = time.Date(2020, 5, 1, 9, 30, 0, 0, time.UTC)
+var txTime = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
 type MockStub struct {
 	shim.ChaincodeStubInterface
 	mock.Mock
@@ -56,9 +68,117 @@ func (ms *MockStub) DelPrivateData(collection string, key string) error {
 	return args.Error(0)
 }
 
+func (ms *MockStub) PurgePrivateData(collection string, key string) error {
+	args := ms.Called(collection, key)
+
+	return args.Error(0)
+}
+
+func (ms *MockStub) GetState(key string) ([]byte, error) {
+	args := ms.Called(key)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (ms *MockStub) PutState(key string, value []byte) error {
+	args := ms.Called(key, value)
+
+	return args.Error(0)
+}
+
+func (ms *MockStub) DelState(key string) error {
+	args := ms.Called(key)
+
+	return args.Error(0)
+}
+
+func (ms *MockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	args := ms.Called(objectType, keys)
+
+	return args.Get(0).(*MockIterator), args.Error(1)
+}
+
+func (ms *MockStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	args := ms.Called(collection, startKey, endKey)
+
+	return args.Get(0).(*MockIterator), args.Error(1)
+}
+
+func (ms *MockStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	args := ms.Called(collection, query)
+
+	return args.Get(0).(*MockIterator), args.Error(1)
+}
+
+func (ms *MockStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	args := ms.Called(collection, objectType, keys)
+
+	return args.Get(0).(*MockIterator), args.Error(1)
+}
+
+func (ms *MockStub) SetEvent(name string, payload []byte) error {
+	args := ms.Called(name, payload)
+
+	return args.Error(0)
+}
+
+func (ms *MockStub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	args := ms.Called(collection, key, ep)
+
+	return args.Error(0)
+}
+
+func (ms *MockStub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	args := ms.Called(collection, key)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (ms *MockStub) SetStateValidationParameter(key string, ep []byte) error {
+	args := ms.Called(key, ep)
+
+	return args.Error(0)
+}
+
+func (ms *MockStub) GetTxID() string {
+	return "txid"
+}
+
+func (ms *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return &timestamp.Timestamp{Seconds: txTime.Unix(), Nanos: int32(txTime.Nanosecond())}, nil
+}
+
+func (ms *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return new(shim.ChaincodeStub).CreateCompositeKey(objectType, attributes)
+}
+
+func (ms *MockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	return new(shim.ChaincodeStub).SplitCompositeKey(compositeKey)
+}
+
+type MockIterator struct {
+	results []*queryresult.KV
+}
+
+func (mi *MockIterator) HasNext() bool {
+	return len(mi.results) > 0
+}
+
+func (mi *MockIterator) Next() (*queryresult.KV, error) {
+	result := mi.results[0]
+	mi.results = mi.results[1:]
+
+	return result, nil
+}
+
+func (mi *MockIterator) Close() error {
+	return nil
+}
+
 type MockClientIdentity struct {
 	cid.ClientIdentity
 	mock.Mock
+	attributes map[string]string
 }
 
 func (mci *MockClientIdentity) GetMSPID() (string, error) {
@@ -66,6 +186,28 @@ func (mci *MockClientIdentity) GetMSPID() (string, error) {
 	return args.Get(0).(string), args.Error(1)
 }
 
+func (mci *MockClientIdentity) GetID() (string, error) {
+	args := mci.Called()
+	return args.Get(0).(string), args.Error(1)
+}
+
+func (mci *MockClientIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	value, found := mci.attributes[attrName]
+	if !found {
+		return fmt.Errorf("Attribute '%s' was not found", attrName)
+	} else if value != attrValue {
+		return fmt.Errorf("Attribute '%s' equals '%s', not '%s'", attrName, value, attrValue)
+	}
+
+	return nil
+}
+
+func (mci *MockClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := mci.attributes[attrName]
+
+	return value, found, nil
+}
+
 type MockContext struct {
 	contractapi.TransactionContextInterface
 	mock.Mock
@@ -83,13 +225,37 @@ func (mc *MockContext) GetClientIdentity() cid.ClientIdentity {
 	return args.Get(0).(*MockClientIdentity)
 }
 
+func newTestCryptoMotionCoin() *CryptoMotionCoin {
+	cryptoMotionCoin := new(CryptoMotionCoin)
+	cryptoMotionCoin.Owner = "user1"
+	cryptoMotionCoin.Amount = 100
+	cryptoMotionCoin.Denomination = "CMC"
+	cryptoMotionCoin.IssuerMSP = "Org1MSP"
+	cryptoMotionCoin.CreatorID = "x509::CN=user1"
+	cryptoMotionCoin.Status = StatusActive
+	cryptoMotionCoin.CreatedAt = createdTime
+	cryptoMotionCoin.UpdatedAt = createdTime
+
+	return cryptoMotionCoin
+}
+
+func setClientAttribute(ctx *MockContext, attrName string, attrValue string) {
+	ctx.GetClientIdentity().(*MockClientIdentity).attributes[attrName] = attrValue
+}
+
+func setClientID(ctx *MockContext, id string) {
+	for _, call := range ctx.GetClientIdentity().(*MockClientIdentity).ExpectedCalls {
+		if call.Method == "GetID" {
+			call.ReturnArguments = mock.Arguments{id, nil}
+		}
+	}
+}
+
 func configureStub() (*MockContext, *MockStub) {
 	var nilBytes []byte
 	transient = make(map[string][]byte)
 
-	testCryptoMotionCoin := new(CryptoMotionCoin)
-	testCryptoMotionCoin.PrivateValue = "set value"
-	cryptoMotionCoinBytes, _ := json.Marshal(testCryptoMotionCoin)
+	cryptoMotionCoinBytes, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
 	hashToVerify := sha256.New()
 	hashToVerify.Write(cryptoMotionCoinBytes)
 
@@ -103,10 +269,25 @@ func configureStub() (*MockContext, *MockStub) {
 	ms.On("GetPrivateDataHash", mock.AnythingOfType("string"), "statebad").Return(nilBytes, errors.New(getStateError))
 	ms.On("GetPrivateDataHash", mock.AnythingOfType("string"), "missingkey").Return(nilBytes, nil)
 	ms.On("GetPrivateDataHash", mock.AnythingOfType("string"), "existingkey").Return([]byte("some hash value"), nil)
+	ms.On("GetPrivateDataHash", "_implicit_org_Org2MSP", "cryptoMotionCoinkey").Return(nilBytes, nil)
 	ms.On("GetPrivateDataHash", mock.AnythingOfType("string"), "cryptoMotionCoinkey").Return(hashToVerify.Sum(nil), nil)
+	ms.On("GetState", "statebad").Return(nilBytes, errors.New(getStateError))
+	ms.On("GetState", "missingkey").Return(nilBytes, nil)
+	ms.On("GetState", "existingkey").Return(nilBytes, nil)
+	ms.On("GetState", "cryptoMotionCoinkey").Return(nilBytes, nil)
+	ms.On("PutState", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).Return(nil)
+	ms.On("DelState", mock.AnythingOfType("string")).Return(nil)
+	ms.On("SetEvent", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).Return(nil)
+	ms.On("SetPrivateDataValidationParameter", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).Return(nil)
+	ms.On("SetStateValidationParameter", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).Return(nil)
+	configurePublicStub(ms, cryptoMotionCoinBytes)
+	configureHistoryHeads(ms, "missingkey", "existingkey", "cryptoMotionCoinkey", "publickey", "publiconlykey")
+	configureAssetHolds(ms, "missingkey", "existingkey", "cryptoMotionCoinkey", "publickey", "publiconlykey")
 
 	mci := new(MockClientIdentity)
+	mci.attributes = map[string]string{roleAttribute: roleIssuer}
 	mci.On("GetMSPID").Return("Org1MSP", nil)
+	mci.On("GetID").Return("x509::CN=user1", nil)
 
 	mc := new(MockContext)
 	mc.On("GetStub").Return(ms)
@@ -115,6 +296,44 @@ func configureStub() (*MockContext, *MockStub) {
 	return mc, ms
 }
 
+func TestGetCollectionName(t *testing.T) {
+	var collectionName string
+	var err error
+
+	ctx, _ := configureStub()
+
+	collectionName, err = getCollectionName(ctx)
+	assert.Nil(t, err, "should not return error when no collection is requested")
+	assert.Equal(t, "_implicit_org_Org1MSP", collectionName, "should default to the implicit collection of the client organization")
+
+	transient[collectionTransientKey] = []byte("CryptoMotionCoinShared")
+	collectionName, err = getCollectionName(ctx)
+	assert.Nil(t, err, "should not return error when a defined collection is requested")
+	assert.Equal(t, "CryptoMotionCoinShared", collectionName, "should use the requested collection")
+
+	transient[collectionTransientKey] = []byte("unknown")
+	_, err = getCollectionName(ctx)
+	assert.EqualError(t, err, "[INVALID_INPUT] The collection unknown is not defined", "should error when the requested collection is not defined")
+
+	collectionConfigs = append(collections.Default(), collections.Shared("Org2Only", "Org2MSP"))
+	defer func() { collectionConfigs = collections.Default() }()
+
+	transient[collectionTransientKey] = []byte("Org2Only")
+	_, err = getCollectionName(ctx)
+	assert.EqualError(t, err, "[UNAUTHORIZED] The organization Org1MSP is not a member of the collection Org2Only", "should error when the client organization is not a member")
+}
+
+func TestCreateCompositeKey(t *testing.T) {
+	ctx, _ := configureStub()
+
+	key, err := createCompositeKey(ctx, holdObjectType, []string{"hold1"})
+	assert.Nil(t, err, "should not return error for valid attributes")
+	assert.Equal(t, "\x00hold\x00hold1\x00", key)
+
+	_, err = createCompositeKey(ctx, holdObjectType, []string{"\xff"})
+	assert.True(t, errors.Is(err, cmcerrors.LedgerError), "should return a coded error when an attribute is not valid UTF-8")
+}
+
 func TestCryptoMotionCoinExists(t *testing.T) {
 	var exists bool
 	var err error
@@ -123,7 +342,7 @@ func TestCryptoMotionCoinExists(t *testing.T) {
 	c := new(CryptoMotionCoinContract)
 
 	exists, err = c.CryptoMotionCoinExists(ctx, "statebad")
-	assert.EqualError(t, err, getStateError)
+	assert.EqualError(t, err, fmt.Sprintf("[LEDGER_ERROR] Could not read from world state. %s", getStateError))
 	assert.False(t, exists, "should return false on error")
 
 	exists, err = c.CryptoMotionCoinExists(ctx, "missingkey")
@@ -142,18 +361,29 @@ func TestCreateCryptoMotionCoin(t *testing.T) {
 	c := new(CryptoMotionCoinContract)
 
 	err = c.CreateCryptoMotionCoin(ctx, "statebad")
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+	assert.EqualError(t, err, fmt.Sprintf("[LEDGER_ERROR] Could not read from world state. %s", getStateError), "should error when exists errors")
 
 	err = c.CreateCryptoMotionCoin(ctx, "existingkey")
-	assert.EqualError(t, err, "The asset existingkey already exists", "should error when exists returns true")
+	assert.EqualError(t, err, "[ALREADY_EXISTS] The asset existingkey already exists", "should error when exists returns true")
 
 	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
-	assert.EqualError(t, err, "The privateValue key was not specified in transient data. Please try again")
+	assert.EqualError(t, err, "[INVALID_INPUT] The cryptoMotionCoin key was not specified in transient data. Please try again")
 
-	transient["privateValue"] = []byte("some value")
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"privateValue":"some value"}`)
+	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] Could not unmarshal transient data to type CryptoMotionCoin. json: unknown field \"privateValue\"", "should error when transient data has unknown fields")
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"","amount":0,"denomination":"cmc","status":"LOST"}`)
+	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The asset is not valid. owner must be specified; amount must be a positive integer; denomination must be 1 to 16 upper case letters or digits; status must be one of ACTIVE, FROZEN or REDEEMED", "should error when fields are not valid")
+	var validationErrors ValidationErrors
+	assert.True(t, errors.As(err, &validationErrors), "should return field level validation errors")
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user2","amount":50,"denomination":"CMC","metadata":{"note":"<gift>"}}`)
 	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
 	assert.Nil(t, err, "should not return error when transaction data provided")
-	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "missingkey", []byte("{\"privateValue\":\"some value\"}"))
+	stub.AssertNotCalled(t, "PutState", "missingkey", mock.Anything)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "missingkey", []byte(`{"data":{"amount":50,"createdAt":"2020-06-01T12:00:00Z","creatorID":"x509::CN=user1","denomination":"CMC","issuerMSP":"Org1MSP","metadata":{"note":"<gift>"},"owner":"user2","status":"ACTIVE","updatedAt":"2020-06-01T12:00:00Z"},"schemaVersion":2}`))
 }
 
 func TestReadCryptoMotionCoin(t *testing.T) {
@@ -164,22 +394,20 @@ func TestReadCryptoMotionCoin(t *testing.T) {
 	c := new(CryptoMotionCoinContract)
 
 	cryptoMotionCoin, err = c.ReadCryptoMotionCoin(ctx, "statebad")
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors when reading")
+	assert.EqualError(t, err, fmt.Sprintf("[LEDGER_ERROR] Could not read from world state. %s", getStateError), "should error when exists errors when reading")
 	assert.Nil(t, cryptoMotionCoin, "should not return CryptoMotionCoin when exists errors when reading")
 
 	cryptoMotionCoin, err = c.ReadCryptoMotionCoin(ctx, "missingkey")
-	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when reading")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when exists returns true when reading")
 	assert.Nil(t, cryptoMotionCoin, "should not return CryptoMotionCoin when key does not exist in private data collection when reading")
 
 	cryptoMotionCoin, err = c.ReadCryptoMotionCoin(ctx, "existingkey")
-	assert.EqualError(t, err, "Could not unmarshal private data collection data to type CryptoMotionCoin", "should error when data in key is not CryptoMotionCoin")
+	assert.EqualError(t, err, "[LEDGER_ERROR] Could not unmarshal private data collection data to type CryptoMotionCoin. invalid character 's' looking for beginning of value", "should error when data in key is not CryptoMotionCoin")
 	assert.Nil(t, cryptoMotionCoin, "should not return CryptoMotionCoin when data in key is not of type CryptoMotionCoin")
 
 	cryptoMotionCoin, err = c.ReadCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
-	expectedCryptoMotionCoin := new(CryptoMotionCoin)
-	expectedCryptoMotionCoin.PrivateValue = "set value"
 	assert.Nil(t, err, "should not return error when CryptoMotionCoin exists in private data collection when reading")
-	assert.Equal(t, expectedCryptoMotionCoin, cryptoMotionCoin, "should return deserialized CryptoMotionCoin from private data collection")
+	assert.Equal(t, newTestCryptoMotionCoin(), cryptoMotionCoin, "should return deserialized CryptoMotionCoin from private data collection")
 }
 
 func TestUpdateCryptoMotionCoin(t *testing.T) {
@@ -189,16 +417,25 @@ func TestUpdateCryptoMotionCoin(t *testing.T) {
 	c := new(CryptoMotionCoinContract)
 
 	err = c.UpdateCryptoMotionCoin(ctx, "statebad")
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors when updating")
+	assert.EqualError(t, err, fmt.Sprintf("[LEDGER_ERROR] Could not read from world state. %s", getStateError), "should error when exists errors when updating")
 
 	err = c.UpdateCryptoMotionCoin(ctx, "missingkey")
-	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists is false when updating")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when exists is false when updating")
+
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The cryptoMotionCoin key was not specified in transient data. Please try again", "should error when no transient data is provided when updating")
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user3","amount":-1,"denomination":"CMC"}`)
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The asset is not valid. amount must be a positive integer", "should error when fields are not valid when updating")
 
-	transient["privateValue"] = []byte("new value")
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user3","amount":75,"denomination":"CMC"}`)
 	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
-	expectedCryptoMotionCoin := new(CryptoMotionCoin)
-	expectedCryptoMotionCoin.PrivateValue = "new value"
-	expectedCryptoMotionCoinBytes, _ := json.Marshal(expectedCryptoMotionCoin)
+	expectedCryptoMotionCoin := newTestCryptoMotionCoin()
+	expectedCryptoMotionCoin.Owner = "user3"
+	expectedCryptoMotionCoin.Amount = 75
+	expectedCryptoMotionCoin.UpdatedAt = txTime
+	expectedCryptoMotionCoinBytes, _ := encodeCryptoMotionCoin(expectedCryptoMotionCoin)
 	assert.Nil(t, err, "should not return error when CryptoMotionCoin exists in private data collection when updating")
 	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "cryptoMotionCoinkey", expectedCryptoMotionCoinBytes)
 }
@@ -210,10 +447,10 @@ func TestDeleteCryptoMotionCoin(t *testing.T) {
 	c := new(CryptoMotionCoinContract)
 
 	err = c.DeleteCryptoMotionCoin(ctx, "statebad")
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+	assert.EqualError(t, err, fmt.Sprintf("[LEDGER_ERROR] Could not read from world state. %s", getStateError), "should error when exists errors")
 
 	err = c.DeleteCryptoMotionCoin(ctx, "missingkey")
-	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns false when deleting")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when exists returns false when deleting")
 
 	err = c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
 	assert.Nil(t, err, "should not return error when CryptoMotionCoin exists in private data collection when deleting")
@@ -228,19 +465,121 @@ func TestVerifyCryptoMotionCoin(t *testing.T) {
 	ctx, stub := configureStub()
 	c := new(CryptoMotionCoinContract)
 
-	cryptoMotionCoin = new(CryptoMotionCoin)
-	cryptoMotionCoin.PrivateValue = "set value"
+	cryptoMotionCoin = newTestCryptoMotionCoin()
 
 	exists, err = c.VerifyCryptoMotionCoin(ctx, "Org1MSP", "statebad", cryptoMotionCoin)
 	assert.False(t, exists, "should return false when unable to read the hash")
-	assert.EqualError(t, err, getStateError)
+	assert.EqualError(t, err, fmt.Sprintf("[LEDGER_ERROR] Could not read from world state. %s", getStateError), "should error when unable to read the hash")
+	assert.True(t, errors.Is(err, cmcerrors.LedgerError))
 
 	exists, err = c.VerifyCryptoMotionCoin(ctx, "Org1MSP", "missingkey", cryptoMotionCoin)
 	assert.False(t, exists, "should return false when key does not exist")
-	assert.EqualError(t, err, "No private data hash with the Key: missingkey", "should error when key does not exist")
+	assert.EqualError(t, err, "[NOT_FOUND] No private data hash with the Key: missingkey", "should error when key does not exist")
 
 	exists, err = c.VerifyCryptoMotionCoin(ctx, "Org1MSP", "cryptoMotionCoinkey", cryptoMotionCoin)
 	assert.True(t, exists, "should return true when hash in world state matched hash from data collection")
 	assert.Nil(t, err, "should not return error when hash in world state matched hash from data collection")
 	stub.AssertCalled(t, "GetPrivateDataHash", "_implicit_org_Org1MSP", "cryptoMotionCoinkey")
+
+	transient[collectionTransientKey] = []byte("CryptoMotionCoinShared")
+	exists, err = c.VerifyCryptoMotionCoin(ctx, "Org1MSP", "cryptoMotionCoinkey", cryptoMotionCoin)
+	assert.True(t, exists, "should verify assets of named collections")
+	assert.Nil(t, err)
+	stub.AssertCalled(t, "GetPrivateDataHash", "CryptoMotionCoinShared", "cryptoMotionCoinkey")
+
+	transient[collectionTransientKey] = []byte("unknown")
+	_, err = c.VerifyCryptoMotionCoin(ctx, "Org1MSP", "cryptoMotionCoinkey", cryptoMotionCoin)
+	assert.EqualError(t, err, "[INVALID_INPUT] The collection unknown is not defined", "should resolve the requested collection")
+
+	delete(transient, collectionTransientKey)
+	_, err = c.VerifyCryptoMotionCoinBytes(ctx, "", "missingkey", "{}")
+	assert.EqualError(t, err, "[NOT_FOUND] No private data hash with the Key: missingkey")
+	stub.AssertCalled(t, "GetPrivateDataHash", "_implicit_org_Org1MSP", "missingkey")
+}
+
+func TestCryptoMotionCoinContractMetadata(t *testing.T) {
+	_, err := contractapi.NewChaincode(new(CryptoMotionCoinContract))
+	assert.Nil(t, err, "should create chaincode from CryptoMotionCoinContract")
+}
+
+func TestVerifyCryptoMotionCoinBytes(t *testing.T) {
+	var exists bool
+	var err error
+
+	ctx, _ := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	exists, err = c.VerifyCryptoMotionCoinBytes(ctx, "Org1MSP", "cryptoMotionCoinkey", `{
+		"owner": "user1",
+		"amount": 1.00e2,
+		"denomination": "CMC",
+		"issuerMSP": "Org1MSP",
+		"creatorID": "x509::CN=user1",
+		"status": "ACTIVE",
+		"createdAt": "2020-05-01T09:30:00Z",
+		"updatedAt": "2020-05-01T09:30:00Z"
+	}`)
+	assert.True(t, exists, "should return true when the canonical form of the document matches the hash")
+	assert.Nil(t, err, "should not return error when the canonical form of the document matches the hash")
+
+	exists, err = c.VerifyCryptoMotionCoinBytes(ctx, "Org1MSP", "cryptoMotionCoinkey", `{"owner":"user2","amount":100,"denomination":"CMC","issuerMSP":"Org1MSP","status":"ACTIVE","createdAt":"2020-05-01T09:30:00Z","updatedAt":"2020-05-01T09:30:00Z"}`)
+	assert.False(t, exists, "should return false when the document differs")
+	assert.Nil(t, err, "should not return error when the document differs")
+
+	exists, err = c.VerifyCryptoMotionCoinBytes(ctx, "Org1MSP", "cryptoMotionCoinkey", "not json")
+	assert.False(t, exists, "should return false when the document is not JSON")
+	assert.Error(t, err, "should error when the document is not JSON")
+}
+
+func TestErrorCodes(t *testing.T) {
+	var err error
+
+	ctx, _ := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	_, err = c.ReadCryptoMotionCoin(ctx, "missingkey")
+	assert.True(t, errors.Is(err, cmcerrors.NotFound), "should return a not found error for a missing asset")
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user1","amount":5,"denomination":"CMC"}`)
+	err = c.CreateCryptoMotionCoin(ctx, "existingkey")
+	assert.True(t, errors.Is(err, cmcerrors.AlreadyExists), "should return an already exists error for an existing asset")
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"","amount":5,"denomination":"CMC"}`)
+	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.True(t, errors.Is(err, cmcerrors.InvalidInput), "should return an invalid input error for an invalid asset")
+
+	_, err = c.ReadCryptoMotionCoin(ctx, "statebad")
+	assert.True(t, errors.Is(err, cmcerrors.LedgerError), "should return a ledger error when the ledger cannot be read")
+	assert.Equal(t, cmcerrors.LedgerError, cmcerrors.ParseCode(err.Error()), "should start the message with the code")
+
+	setClientAttribute(ctx, roleAttribute, roleAuditor)
+	err = c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.True(t, errors.Is(err, cmcerrors.Unauthorized), "should return an unauthorized error when the role is not allowed")
+}
+
+func TestCryptoMotionCoinDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	created := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	updated := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 250, Denomination: "CMC"})
+
+	assert.Equal(t, "false", invokeScenario(t, ledger, chaincode, issuer, nil, "CryptoMotionCoinExists", "coin1"))
+
+	invokeScenario(t, ledger, chaincode, issuer, created, "CreateCryptoMotionCoin", "coin1")
+	assert.Equal(t, "true", invokeScenario(t, ledger, chaincode, issuer, nil, "CryptoMotionCoinExists", "coin1"))
+
+	payload := invokeScenario(t, ledger, chaincode, issuer, nil, "ReadCryptoMotionCoin", "coin1")
+	read := new(CryptoMotionCoin)
+	require.NoError(t, json.Unmarshal([]byte(payload), read))
+	assert.Equal(t, int64(100), read.Amount, "should return assets without metadata")
+	assert.Nil(t, read.Metadata)
+
+	assert.Equal(t, "true", invokeScenario(t, ledger, chaincode, issuer, nil, "VerifyCryptoMotionCoinBytes", "Org1MSP", "coin1", payload))
+
+	invokeScenario(t, ledger, chaincode, issuer, updated, "UpdateCryptoMotionCoin", "coin1")
+	assert.Equal(t, "false", invokeScenario(t, ledger, chaincode, issuer, nil, "VerifyCryptoMotionCoinBytes", "Org1MSP", "coin1", payload), "should not verify outdated assets")
+
+	invokeScenario(t, ledger, chaincode, issuer, nil, "DeleteCryptoMotionCoin", "coin1")
+	assert.Equal(t, "false", invokeScenario(t, ledger, chaincode, issuer, nil, "CryptoMotionCoinExists", "coin1"))
 }
@@ -0,0 +1,152 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// CryptoMotionCoinEndorsementPolicy lists the organizations whose peers must all endorse a change to a CryptoMotionCoin.
+// An empty list means no key-level policy is set and the chaincode endorsement policy applies
+type CryptoMotionCoinEndorsementPolicy struct {
+	Orgs []string `json:"orgs"`
+}
+
+// newEndorsementPolicy returns a key-level endorsement policy requiring the peers of every organization
+func newEndorsementPolicy(orgs []string) ([]byte, error) {
+	if len(orgs) == 0 {
+		return nil, cmcerrors.InvalidInputf("At least one organization must be specified")
+	}
+
+	for _, org := range orgs {
+		if org == "" {
+			return nil, cmcerrors.InvalidInputf("The organizations must not be blank")
+		}
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return nil, cmcerrors.Internalf("Could not create an endorsement policy. %w", err)
+	}
+
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgs...)
+	if err != nil {
+		return nil, cmcerrors.Internalf("Could not add the organizations to the endorsement policy. %w", err)
+	}
+
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return nil, cmcerrors.Internalf("Could not encode the endorsement policy. %w", err)
+	}
+
+	return policy, nil
+}
+
+// setCryptoMotionCoinEndorsementPolicy sets the key-level endorsement policy of a CryptoMotionCoin and, when public is set, of its
+// public record. Callers say whether there is a public record since writes are not visible to reads in the same transaction
+func setCryptoMotionCoinEndorsementPolicy(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, orgs []string, public bool) error {
+	policy, err := newEndorsementPolicy(orgs)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetPrivateDataValidationParameter(collectionName, cryptoMotionCoinID, policy)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not set the endorsement policy of asset %s. %w", cryptoMotionCoinID, err)
+	}
+
+	if !public {
+		return nil
+	}
+
+	publicKey, err := getPublicKey(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetStateValidationParameter(publicKey, policy)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not set the endorsement policy of asset %s. %w", cryptoMotionCoinID, err)
+	}
+
+	return nil
+}
+
+// SetCryptoMotionCoinEndorsementPolicy requires the peers of every given organization to endorse future changes to a CryptoMotionCoin,
+// for example the owning organization and an auditor organization
+func (c *CryptoMotionCoinContract) SetCryptoMotionCoinEndorsementPolicy(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string, orgs []string) error {
+	err := authorize(ctx, "SetCryptoMotionCoinEndorsementPolicy")
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.ReadCryptoMotionCoin(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = assertCreator(ctx, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return err
+	}
+
+	public, err := getPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	return setCryptoMotionCoinEndorsementPolicy(ctx, collectionName, cryptoMotionCoinID, orgs, public != nil)
+}
+
+// GetCryptoMotionCoinEndorsementPolicy returns the organizations whose peers must endorse changes to a CryptoMotionCoin
+func (c *CryptoMotionCoinContract) GetCryptoMotionCoinEndorsementPolicy(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) (*CryptoMotionCoinEndorsementPolicy, error) {
+	err := authorize(ctx, "GetCryptoMotionCoinEndorsementPolicy")
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := c.CryptoMotionCoinExists(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, cmcerrors.NotFoundf("The asset %s does not exist", cryptoMotionCoinID)
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ctx.GetStub().GetPrivateDataValidationParameter(collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read the endorsement policy of asset %s. %w", cryptoMotionCoinID, err)
+	}
+
+	result := &CryptoMotionCoinEndorsementPolicy{Orgs: []string{}}
+
+	if len(policy) == 0 {
+		return result, nil
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read the endorsement policy of asset %s. %w", cryptoMotionCoinID, err)
+	}
+
+	result.Orgs = endorsementPolicy.ListOrgs()
+	sort.Strings(result.Orgs)
+
+	return result, nil
+}
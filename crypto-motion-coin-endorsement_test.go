@@ -0,0 +1,108 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func policyOf(orgs ...string) []byte {
+	policy, _ := newEndorsementPolicy(orgs)
+	return policy
+}
+
+func TestNewEndorsementPolicy(t *testing.T) {
+	var err error
+
+	_, err = newEndorsementPolicy([]string{})
+	assert.EqualError(t, err, "[INVALID_INPUT] At least one organization must be specified")
+
+	_, err = newEndorsementPolicy([]string{"Org1MSP", ""})
+	assert.EqualError(t, err, "[INVALID_INPUT] The organizations must not be blank")
+
+	assert.Equal(t, policyOf("Org1MSP", "Org2MSP"), policyOf("Org2MSP", "Org1MSP"), "should not depend on the order of the organizations")
+}
+
+func TestCreateCryptoMotionCoinEndorsementPolicy(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user2","amount":50,"denomination":"CMC"}`)
+	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.Nil(t, err, "should not return error when creating")
+	stub.AssertCalled(t, "SetPrivateDataValidationParameter", "_implicit_org_Org1MSP", "missingkey", policyOf("Org1MSP"))
+	stub.AssertNotCalled(t, "SetStateValidationParameter", publicKey(stub, "_implicit_org_Org1MSP", "missingkey"), mock.Anything)
+
+	transient[publicTransientKey] = []byte("true")
+	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.Nil(t, err, "should not return error when creating a public asset")
+	stub.AssertCalled(t, "SetStateValidationParameter", publicKey(stub, "_implicit_org_Org1MSP", "missingkey"), policyOf("Org1MSP"))
+}
+
+func TestSetCryptoMotionCoinEndorsementPolicy(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	err = c.SetCryptoMotionCoinEndorsementPolicy(ctx, "missingkey", []string{"Org1MSP"})
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when the asset does not exist")
+
+	err = c.SetCryptoMotionCoinEndorsementPolicy(ctx, "cryptoMotionCoinkey", []string{})
+	assert.EqualError(t, err, "[INVALID_INPUT] At least one organization must be specified", "should error without organizations")
+
+	err = c.SetCryptoMotionCoinEndorsementPolicy(ctx, "cryptoMotionCoinkey", []string{"Org1MSP", "AuditorMSP"})
+	assert.Nil(t, err, "should not return error when setting a policy")
+	stub.AssertCalled(t, "SetPrivateDataValidationParameter", "_implicit_org_Org1MSP", "cryptoMotionCoinkey", policyOf("AuditorMSP", "Org1MSP"))
+
+	err = c.SetCryptoMotionCoinEndorsementPolicy(ctx, "publickey", []string{"Org1MSP", "AuditorMSP"})
+	assert.Nil(t, err, "should not return error when setting the policy of a public asset")
+	stub.AssertCalled(t, "SetStateValidationParameter", publicKey(stub, "_implicit_org_Org1MSP", "publickey"), policyOf("AuditorMSP", "Org1MSP"))
+
+	setClientAttribute(ctx, roleAttribute, roleOperator)
+	err = c.SetCryptoMotionCoinEndorsementPolicy(ctx, "cryptoMotionCoinkey", []string{"Org1MSP"})
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. The issuer role is required for SetCryptoMotionCoinEndorsementPolicy", "should error when the client is not an issuer")
+}
+
+func TestGetCryptoMotionCoinEndorsementPolicy(t *testing.T) {
+	var policy *CryptoMotionCoinEndorsementPolicy
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stub.On("GetPrivateDataValidationParameter", "_implicit_org_Org1MSP", "cryptoMotionCoinkey").Return(policyOf("Org1MSP", "AuditorMSP"), nil)
+	stub.On("GetPrivateDataValidationParameter", "_implicit_org_Org1MSP", "existingkey").Return([]byte(nil), nil)
+	stub.On("GetPrivateDataValidationParameter", "_implicit_org_Org1MSP", "publickey").Return([]byte(nil), errors.New("metadata error"))
+
+	_, err = c.GetCryptoMotionCoinEndorsementPolicy(ctx, "missingkey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when the asset does not exist")
+
+	_, err = c.GetCryptoMotionCoinEndorsementPolicy(ctx, "publickey")
+	assert.EqualError(t, err, "[LEDGER_ERROR] Could not read the endorsement policy of asset publickey. metadata error", "should error when the policy cannot be read")
+
+	policy, err = c.GetCryptoMotionCoinEndorsementPolicy(ctx, "existingkey")
+	assert.Nil(t, err, "should not return error when no policy is set")
+	assert.Equal(t, &CryptoMotionCoinEndorsementPolicy{Orgs: []string{}}, policy, "should return no organizations when no policy is set")
+
+	policy, err = c.GetCryptoMotionCoinEndorsementPolicy(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when a policy is set")
+	assert.Equal(t, &CryptoMotionCoinEndorsementPolicy{Orgs: []string{"AuditorMSP", "Org1MSP"}}, policy, "should return the sorted organizations of the policy")
+}
+
+func TestTransferCryptoMotionCoinEndorsementPolicy(t *testing.T) {
+	ctx, stub := configureTransferStub()
+	c := new(CryptoMotionCoinContract)
+
+	err := c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org2MSP")
+	assert.Nil(t, err, "should not return error when transferring")
+	stub.AssertCalled(t, "SetPrivateDataValidationParameter", "_implicit_org_Org2MSP", "cryptoMotionCoinkey", policyOf("Org2MSP"))
+}
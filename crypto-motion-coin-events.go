@@ -0,0 +1,29 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/events"
+)
+
+// emitCryptoMotionCoinEvent sets the chaincode event of the transaction. Fabric keeps only the last event set
+func emitCryptoMotionCoinEvent(ctx contractapi.TransactionContextInterface, event events.Event) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return cmcerrors.Internalf("Could not marshal event %s. %w", event.Name(), err)
+	}
+
+	err = ctx.GetStub().SetEvent(event.Name(), bytes)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not set event %s. %w", event.Name(), err)
+	}
+
+	return nil
+}
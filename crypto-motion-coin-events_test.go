@@ -0,0 +1,45 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCryptoMotionCoinEvents(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user2","amount":50,"denomination":"CMC"}`)
+	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.Nil(t, err, "should not return error when creating")
+	created := &CryptoMotionCoin{Owner: "user2", Amount: 50, Denomination: "CMC", IssuerMSP: "Org1MSP", CreatorID: "x509::CN=user1", Status: StatusActive, CreatedAt: txTime, UpdatedAt: txTime}
+	createdBytes, _ := encodeCryptoMotionCoin(created)
+	stub.AssertCalled(t, "SetEvent", "CryptoMotionCoinCreated", []byte(`{"version":1,"cryptoMotionCoinID":"missingkey","collection":"_implicit_org_Org1MSP","valueHash":"`+getAppraisalHash(createdBytes)+`"}`))
+
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when updating")
+	stub.AssertCalled(t, "SetEvent", "CryptoMotionCoinUpdated", mock.AnythingOfType("[]uint8"))
+
+	err = c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when deleting")
+	stub.AssertCalled(t, "SetEvent", "CryptoMotionCoinDeleted", []byte(`{"version":1,"cryptoMotionCoinID":"cryptoMotionCoinkey","collection":"_implicit_org_Org1MSP"}`))
+
+}
+
+func TestTransferCryptoMotionCoinEvent(t *testing.T) {
+	ctx, stub := configureTransferStub()
+	c := new(CryptoMotionCoinContract)
+
+	err := c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org2MSP")
+	assert.Nil(t, err, "should not return error when transferring")
+	stored, _ := encodeCryptoMotionCoin(newTransferredCryptoMotionCoin())
+	stub.AssertCalled(t, "SetEvent", "CryptoMotionCoinTransferred", []byte(`{"version":1,"cryptoMotionCoinID":"cryptoMotionCoinkey","collection":"_implicit_org_Org2MSP","valueHash":"`+getAppraisalHash(stored)+`","fromCollection":"_implicit_org_Org1MSP"}`))
+}
@@ -0,0 +1,307 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// Private data has no history API so every change to a CryptoMotionCoin appends an entry to an audit trail kept in the
+// collection of the organization holding it. A link per entry, chained by hash, is written to world state so any
+// organization can detect a trail that was altered or truncated
+const (
+	historyObjectType     = "history"
+	historyHeadObjectType = "historyHead"
+)
+
+// CryptoMotionCoinAction is the kind of change recorded in the history of a CryptoMotionCoin
+type CryptoMotionCoinAction string
+
+// The changes recorded in the history of a CryptoMotionCoin
+const (
+	ActionCreate   CryptoMotionCoinAction = "CREATE"
+	ActionUpdate   CryptoMotionCoinAction = "UPDATE"
+	ActionDelete   CryptoMotionCoinAction = "DELETE"
+	ActionTransfer CryptoMotionCoinAction = "TRANSFER"
+	ActionMigrate  CryptoMotionCoinAction = "MIGRATE"
+	ActionPurge    CryptoMotionCoinAction = "PURGE"
+)
+
+// CryptoMotionCoinHistoryEntry is the private record of one change to a CryptoMotionCoin
+type CryptoMotionCoinHistoryEntry struct {
+	Sequence     int64                  `json:"sequence"`
+	TxID         string                 `json:"txID"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Action       CryptoMotionCoinAction `json:"action"`
+	ClientID     string                 `json:"clientID"`
+	ValueHash    string                 `json:"valueHash,omitempty" metadata:"valueHash,optional"`
+	Value        *CryptoMotionCoin      `json:"value,omitempty" metadata:"value,optional"`
+	PreviousHash string                 `json:"previousHash"`
+}
+
+// CryptoMotionCoinHistoryLink is the public record of one change to a CryptoMotionCoin. Hash is the hash of the private entry
+type CryptoMotionCoinHistoryLink struct {
+	Sequence     int64                  `json:"sequence"`
+	TxID         string                 `json:"txID"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Action       CryptoMotionCoinAction `json:"action"`
+	Hash         string                 `json:"hash"`
+	PreviousHash string                 `json:"previousHash"`
+}
+
+// CryptoMotionCoinHistoryRecord is one change to a CryptoMotionCoin. The private fields are only set when the entry is held
+// in the collection of the client, and Verified reports whether that entry matches the public hash chain
+type CryptoMotionCoinHistoryRecord struct {
+	Sequence     int64                  `json:"sequence"`
+	TxID         string                 `json:"txID"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Action       CryptoMotionCoinAction `json:"action"`
+	Hash         string                 `json:"hash"`
+	PreviousHash string                 `json:"previousHash"`
+	ClientID     string                 `json:"clientID,omitempty" metadata:"clientID,optional"`
+	ValueHash    string                 `json:"valueHash,omitempty" metadata:"valueHash,optional"`
+	Value        *CryptoMotionCoin      `json:"value,omitempty" metadata:"value,optional"`
+	Verified     bool                   `json:"verified"`
+}
+
+// getHistoryKey returns the key of an entry of the history of the CryptoMotionCoin held in a collection. The private entry
+// and its public link share the key, and the zero-padded sequence keeps the entries in order in range queries
+func getHistoryKey(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, sequence int64) (string, error) {
+	return createCollectionKey(ctx, historyObjectType, collectionName, cryptoMotionCoinID, fmt.Sprintf("%020d", sequence))
+}
+
+// getHistoryHeadKey returns the key of the copy of the last link of the history of the CryptoMotionCoin held in a collection
+func getHistoryHeadKey(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (string, error) {
+	return createCollectionKey(ctx, historyHeadObjectType, collectionName, cryptoMotionCoinID)
+}
+
+func getHistoryEntryHash(entry *CryptoMotionCoinHistoryEntry) ([]byte, string, error) {
+	bytes, err := canonicalJSON(entry)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return bytes, getAppraisalHash(bytes), nil
+}
+
+// getHistoryHead returns the last link of the history of the CryptoMotionCoin held in a collection, or nil when nothing was recorded yet
+func getHistoryHead(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (*CryptoMotionCoinHistoryLink, error) {
+	headKey, err := getHistoryHeadKey(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(headKey)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return nil, nil
+	}
+
+	head := new(CryptoMotionCoinHistoryLink)
+
+	err = json.Unmarshal(bytes, head)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not unmarshal world state data to type CryptoMotionCoinHistoryLink. %w", err)
+	}
+
+	return head, nil
+}
+
+// recordCryptoMotionCoinHistory appends a change to the history of a CryptoMotionCoin. The stored bytes and value are nil on deletion
+func recordCryptoMotionCoinHistory(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, action CryptoMotionCoinAction, stored []byte, cryptoMotionCoin *CryptoMotionCoin) error {
+	_, err := appendCryptoMotionCoinHistory(ctx, collectionName, cryptoMotionCoinID, action, stored, cryptoMotionCoin, "")
+	return err
+}
+
+// recordTransferredCryptoMotionCoinHistory closes the history of a CryptoMotionCoin in the collection of the seller with a
+// transfer and links the first entry of the history in the collection of the buyer to it, so the trail survives the transfer
+func recordTransferredCryptoMotionCoinHistory(ctx contractapi.TransactionContextInterface, sellerCollection string, buyerCollection string, cryptoMotionCoinID string, stored []byte, cryptoMotionCoin *CryptoMotionCoin) error {
+	sellerHash, err := appendCryptoMotionCoinHistory(ctx, sellerCollection, cryptoMotionCoinID, ActionTransfer, nil, nil, "")
+	if err != nil {
+		return err
+	}
+
+	_, err = appendCryptoMotionCoinHistory(ctx, buyerCollection, cryptoMotionCoinID, ActionTransfer, stored, cryptoMotionCoin, sellerHash)
+	return err
+}
+
+// appendCryptoMotionCoinHistory writes the next entry of the history of a CryptoMotionCoin and returns its hash. The
+// origin hash is the previous hash of the first entry, set when the history continues one from another collection
+func appendCryptoMotionCoinHistory(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, action CryptoMotionCoinAction, stored []byte, cryptoMotionCoin *CryptoMotionCoin, originHash string) (string, error) {
+	head, err := getHistoryHead(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return "", err
+	}
+
+	clientID, err := getClientAccountID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	entry := new(CryptoMotionCoinHistoryEntry)
+	entry.TxID = ctx.GetStub().GetTxID()
+	entry.Timestamp = now
+	entry.Action = action
+	entry.ClientID = clientID
+	entry.Value = cryptoMotionCoin
+	entry.PreviousHash = originHash
+
+	if stored != nil {
+		entry.ValueHash = getAppraisalHash(stored)
+	}
+
+	if head != nil {
+		entry.Sequence = head.Sequence + 1
+		entry.PreviousHash = head.Hash
+	}
+
+	entryBytes, hash, err := getHistoryEntryHash(entry)
+	if err != nil {
+		return "", err
+	}
+
+	historyKey, err := getHistoryKey(ctx, collectionName, cryptoMotionCoinID, entry.Sequence)
+	if err != nil {
+		return "", err
+	}
+
+	err = ctx.GetStub().PutPrivateData(collectionName, historyKey, entryBytes)
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	link := CryptoMotionCoinHistoryLink{
+		Sequence:     entry.Sequence,
+		TxID:         entry.TxID,
+		Timestamp:    entry.Timestamp,
+		Action:       entry.Action,
+		Hash:         hash,
+		PreviousHash: entry.PreviousHash,
+	}
+
+	linkBytes, err := canonicalJSON(link)
+	if err != nil {
+		return "", err
+	}
+
+	err = ctx.GetStub().PutState(historyKey, linkBytes)
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	headKey, err := getHistoryHeadKey(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return "", err
+	}
+
+	err = ctx.GetStub().PutState(headKey, linkBytes)
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return hash, nil
+}
+
+// GetCryptoMotionCoinHistory returns every change to the CryptoMotionCoin held in the collection of the client, oldest first. The public hash chain is checked
+// from the first link to the head and the private entries held by the client are checked against it. A history that starts
+// with a transfer links to the history in the collection of the seller
+func (c *CryptoMotionCoinContract) GetCryptoMotionCoinHistory(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) ([]CryptoMotionCoinHistoryRecord, error) {
+	err := authorize(ctx, "GetCryptoMotionCoinHistory")
+	if err != nil {
+		return nil, err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := getHistoryHead(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	} else if head == nil {
+		return nil, cmcerrors.NotFoundf("The asset %s does not have any history", cryptoMotionCoinID)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(historyObjectType, []string{collectionName, cryptoMotionCoinID})
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []CryptoMotionCoinHistoryRecord{}
+	previousHash := ""
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		link := new(CryptoMotionCoinHistoryLink)
+
+		err = json.Unmarshal(queryResult.Value, link)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not unmarshal world state data to type CryptoMotionCoinHistoryLink. %w", err)
+		}
+
+		if len(records) == 0 && link.Action == ActionTransfer {
+			previousHash = link.PreviousHash
+		}
+
+		if link.Sequence != int64(len(records)) || link.PreviousHash != previousHash {
+			return nil, cmcerrors.LedgerErrorf("The history of asset %s is broken at sequence %d", cryptoMotionCoinID, len(records))
+		}
+
+		record := CryptoMotionCoinHistoryRecord{
+			Sequence:     link.Sequence,
+			TxID:         link.TxID,
+			Timestamp:    link.Timestamp,
+			Action:       link.Action,
+			Hash:         link.Hash,
+			PreviousHash: link.PreviousHash,
+		}
+
+		entryBytes, err := ctx.GetStub().GetPrivateData(collectionName, queryResult.Key)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		if entryBytes != nil {
+			entry := new(CryptoMotionCoinHistoryEntry)
+
+			err = json.Unmarshal(entryBytes, entry)
+			if err != nil {
+				return nil, cmcerrors.LedgerErrorf("Could not unmarshal private data collection data to type CryptoMotionCoinHistoryEntry. %w", err)
+			}
+
+			record.ClientID = entry.ClientID
+			record.ValueHash = entry.ValueHash
+			record.Value = entry.Value
+			record.Verified = getAppraisalHash(entryBytes) == link.Hash
+		}
+
+		records = append(records, record)
+		previousHash = link.Hash
+	}
+
+	if previousHash != head.Hash || int64(len(records)) != head.Sequence+1 {
+		return nil, cmcerrors.LedgerErrorf("The history of asset %s is broken at sequence %d", cryptoMotionCoinID, len(records))
+	}
+
+	return records, nil
+}
@@ -0,0 +1,177 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+func configureHistoryHeads(ms *MockStub, cryptoMotionCoinIDs ...string) {
+	var nilBytes []byte
+
+	for _, collectionName := range []string{"_implicit_org_Org1MSP", "_implicit_org_Org2MSP"} {
+		for _, cryptoMotionCoinID := range cryptoMotionCoinIDs {
+			headKey, _ := ms.CreateCompositeKey(historyHeadObjectType, []string{collectionName, cryptoMotionCoinID})
+			ms.On("GetState", headKey).Return(nilBytes, nil)
+		}
+	}
+}
+
+func historyEntry(sequence int64, action CryptoMotionCoinAction, previousHash string) *CryptoMotionCoinHistoryEntry {
+	stored, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
+
+	return &CryptoMotionCoinHistoryEntry{
+		Sequence:     sequence,
+		TxID:         "txid",
+		Timestamp:    txTime,
+		Action:       action,
+		ClientID:     clientAccount,
+		ValueHash:    getAppraisalHash(stored),
+		Value:        newTestCryptoMotionCoin(),
+		PreviousHash: previousHash,
+	}
+}
+
+func historyLink(entry *CryptoMotionCoinHistoryEntry) ([]byte, *CryptoMotionCoinHistoryLink) {
+	entryBytes, hash, _ := getHistoryEntryHash(entry)
+
+	return entryBytes, &CryptoMotionCoinHistoryLink{
+		Sequence:     entry.Sequence,
+		TxID:         entry.TxID,
+		Timestamp:    entry.Timestamp,
+		Action:       entry.Action,
+		Hash:         hash,
+		PreviousHash: entry.PreviousHash,
+	}
+}
+
+func TestRecordCryptoMotionCoinHistory(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user1","amount":100,"denomination":"CMC"}`)
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when updating")
+
+	updated := newTestCryptoMotionCoin()
+	updated.UpdatedAt = txTime
+	stored, _ := encodeCryptoMotionCoin(updated)
+	entry := historyEntry(0, ActionUpdate, "")
+	entry.ValueHash = getAppraisalHash(stored)
+	entry.Value = updated
+	entryBytes, link := historyLink(entry)
+	linkBytes, _ := canonicalJSON(link)
+
+	historyKey, _ := stub.CreateCompositeKey(historyObjectType, []string{"_implicit_org_Org1MSP", "cryptoMotionCoinkey", "00000000000000000000"})
+	headKey, _ := stub.CreateCompositeKey(historyHeadObjectType, []string{"_implicit_org_Org1MSP", "cryptoMotionCoinkey"})
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", historyKey, entryBytes)
+	stub.AssertCalled(t, "PutState", historyKey, linkBytes)
+	stub.AssertCalled(t, "PutState", headKey, linkBytes)
+
+	_, head := historyLink(historyEntry(4, ActionCreate, "abcd"))
+	headBytes, _ := canonicalJSON(head)
+	headKey, _ = stub.CreateCompositeKey(historyHeadObjectType, []string{"_implicit_org_Org1MSP", "historykey"})
+	stub.On("GetState", headKey).Return(headBytes, nil)
+
+	err = recordCryptoMotionCoinHistory(ctx, "_implicit_org_Org1MSP", "historykey", ActionDelete, nil, nil)
+	assert.Nil(t, err, "should not return error when appending to an existing history")
+	entryBytes, link = historyLink(&CryptoMotionCoinHistoryEntry{Sequence: 5, TxID: "txid", Timestamp: txTime, Action: ActionDelete, ClientID: clientAccount, PreviousHash: head.Hash})
+	linkBytes, _ = canonicalJSON(link)
+	historyKey, _ = stub.CreateCompositeKey(historyObjectType, []string{"_implicit_org_Org1MSP", "historykey", "00000000000000000005"})
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", historyKey, entryBytes)
+	stub.AssertCalled(t, "PutState", headKey, linkBytes)
+}
+
+func TestGetCryptoMotionCoinHistory(t *testing.T) {
+	var records []CryptoMotionCoinHistoryRecord
+	var err error
+	var nilBytes []byte
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	entry0Bytes, link0 := historyLink(historyEntry(0, ActionCreate, ""))
+	_, link1 := historyLink(historyEntry(1, ActionTransfer, link0.Hash))
+	link0Bytes, _ := canonicalJSON(link0)
+	link1Bytes, _ := canonicalJSON(link1)
+	key0, _ := getHistoryKey(ctx, "_implicit_org_Org1MSP", "historykey", 0)
+	key1, _ := getHistoryKey(ctx, "_implicit_org_Org1MSP", "historykey", 1)
+	headKey, _ := stub.CreateCompositeKey(historyHeadObjectType, []string{"_implicit_org_Org1MSP", "historykey"})
+
+	stub.On("GetState", headKey).Return(link1Bytes, nil)
+	stub.On("GetStateByPartialCompositeKey", historyObjectType, []string{"_implicit_org_Org1MSP", "historykey"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: key0, Value: link0Bytes},
+		{Key: key1, Value: link1Bytes},
+	}}, nil)
+	stub.On("GetPrivateData", "_implicit_org_Org1MSP", key0).Return(entry0Bytes, nil)
+	stub.On("GetPrivateData", "_implicit_org_Org1MSP", key1).Return(nilBytes, nil)
+
+	brokenHeadKey, _ := stub.CreateCompositeKey(historyHeadObjectType, []string{"_implicit_org_Org1MSP", "brokenkey"})
+	stub.On("GetState", brokenHeadKey).Return(link1Bytes, nil)
+	stub.On("GetStateByPartialCompositeKey", historyObjectType, []string{"_implicit_org_Org1MSP", "brokenkey"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: key1, Value: link1Bytes},
+	}}, nil)
+
+	transferredBytes, transferred := historyLink(historyEntry(0, ActionTransfer, "abcd"))
+	transferredLinkBytes, _ := canonicalJSON(transferred)
+	transferredKey, _ := getHistoryKey(ctx, "_implicit_org_Org1MSP", "transferredkey", 0)
+	transferredHeadKey, _ := stub.CreateCompositeKey(historyHeadObjectType, []string{"_implicit_org_Org1MSP", "transferredkey"})
+	stub.On("GetState", transferredHeadKey).Return(transferredLinkBytes, nil)
+	stub.On("GetStateByPartialCompositeKey", historyObjectType, []string{"_implicit_org_Org1MSP", "transferredkey"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: transferredKey, Value: transferredLinkBytes},
+	}}, nil)
+	stub.On("GetPrivateData", "_implicit_org_Org1MSP", transferredKey).Return(transferredBytes, nil)
+
+	_, err = c.GetCryptoMotionCoinHistory(ctx, "missingkey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not have any history", "should error when nothing was recorded")
+
+	_, err = c.GetCryptoMotionCoinHistory(ctx, "brokenkey")
+	assert.EqualError(t, err, "[LEDGER_ERROR] The history of asset brokenkey is broken at sequence 0", "should error when links are missing")
+
+	records, err = c.GetCryptoMotionCoinHistory(ctx, "historykey")
+	assert.Nil(t, err, "should not return error when the history is intact")
+	assert.Equal(t, []CryptoMotionCoinHistoryRecord{
+		{Sequence: 0, TxID: "txid", Timestamp: txTime, Action: ActionCreate, Hash: link0.Hash, ClientID: clientAccount, ValueHash: historyEntry(0, ActionCreate, "").ValueHash, Value: newTestCryptoMotionCoin(), Verified: true},
+		{Sequence: 1, TxID: "txid", Timestamp: txTime, Action: ActionTransfer, Hash: link1.Hash, PreviousHash: link0.Hash},
+	}, records, "should merge the private entries held by the client with the public chain")
+
+	records, err = c.GetCryptoMotionCoinHistory(ctx, "transferredkey")
+	assert.Nil(t, err, "should not return error when the history starts with a transfer")
+	assert.Equal(t, "abcd", records[0].PreviousHash, "should link the history to the one of the seller")
+	assert.True(t, records[0].Verified)
+}
+
+func TestHistoryDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer1 := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	issuer2 := newScenarioIdentity(t, "Org2MSP", "issuer2", roleIssuer)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+
+	invokeScenario(t, ledger, chaincode, issuer1, transient, "CreateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, issuer1, transient, "UpdateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, issuer2, transient, "CreateCryptoMotionCoin", "coin1")
+
+	var records []CryptoMotionCoinHistoryRecord
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer1, nil, "GetCryptoMotionCoinHistory", "coin1")), &records))
+	require.Len(t, records, 2, "should not mix the histories of other collections")
+	assert.Equal(t, ActionUpdate, records[1].Action)
+	assert.True(t, records[0].Verified && records[1].Verified)
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer2, nil, "GetCryptoMotionCoinHistory", "coin1")), &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, ActionCreate, records[0].Action)
+	assert.True(t, records[0].Verified, "should verify the history of each collection")
+}
@@ -0,0 +1,536 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/events"
+)
+
+// Holds are stored in world state by ID. A CryptoMotionCoin locked by a hold also has a record holding the ID of that hold
+const (
+	holdObjectType      = "hold"
+	assetHoldObjectType = "assetHold"
+)
+
+// CryptoMotionCoinHoldStatus is the lifecycle status of a hold
+type CryptoMotionCoinHoldStatus string
+
+// Statuses a hold can be in. Only held holds lock anything
+const (
+	HoldStatusHeld     CryptoMotionCoinHoldStatus = "HELD"
+	HoldStatusExecuted CryptoMotionCoinHoldStatus = "EXECUTED"
+	HoldStatusReleased CryptoMotionCoinHoldStatus = "RELEASED"
+	HoldStatusExpired  CryptoMotionCoinHoldStatus = "EXPIRED"
+)
+
+// CryptoMotionCoinHold locks either an amount of the payer's balance or a CryptoMotionCoin until the notary executes or
+// releases it, or it expires after the deadline. Executing pays the amount to the payee account, or keeps the
+// CryptoMotionCoin locked until its delivery makes the payee its owner and creator. PayeeID is the client ID of the payee
+// of a CryptoMotionCoin, which becomes the creator allowed to modify it once delivered. Releasing and expiry return the amount to the
+// payer or unlock the CryptoMotionCoin
+type CryptoMotionCoinHold struct {
+	ID                 string                     `json:"id"`
+	Payer              string                     `json:"payer"`
+	Payee              string                     `json:"payee"`
+	PayeeID            string                     `json:"payeeID,omitempty" metadata:"payeeID,optional"`
+	Notary             string                     `json:"notary"`
+	Amount             int64                      `json:"amount,omitempty" metadata:"amount,optional"`
+	CryptoMotionCoinID string                     `json:"cryptoMotionCoinID,omitempty" metadata:"cryptoMotionCoinID,optional"`
+	Collection         string                     `json:"collection,omitempty" metadata:"collection,optional"`
+	Deadline           time.Time                  `json:"deadline"`
+	Status             CryptoMotionCoinHoldStatus `json:"status"`
+	Delivered          bool                       `json:"delivered,omitempty" metadata:"delivered,optional"`
+}
+
+func getHoldKey(ctx contractapi.TransactionContextInterface, holdID string) (string, error) {
+	return createCompositeKey(ctx, holdObjectType, []string{holdID})
+}
+
+// getAssetHoldKey returns the key of the record holding the ID of the hold that locks the CryptoMotionCoin held in a collection
+func getAssetHoldKey(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (string, error) {
+	return createCollectionKey(ctx, assetHoldObjectType, collectionName, cryptoMotionCoinID)
+}
+
+// getHold returns a hold, or nil when it does not exist
+func getHold(ctx contractapi.TransactionContextInterface, holdID string) (*CryptoMotionCoinHold, error) {
+	holdKey, err := getHoldKey(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(holdKey)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return nil, nil
+	}
+
+	hold := new(CryptoMotionCoinHold)
+
+	err = json.Unmarshal(bytes, hold)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not unmarshal world state data to type CryptoMotionCoinHold. %w", err)
+	}
+
+	return hold, nil
+}
+
+func putHold(ctx contractapi.TransactionContextInterface, hold *CryptoMotionCoinHold) error {
+	holdKey, err := getHoldKey(ctx, hold.ID)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := canonicalJSON(hold)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(holdKey, bytes)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return nil
+}
+
+// settleHold moves a held amount to the payee when the hold is executed and back to the payer otherwise. A held
+// CryptoMotionCoin is unlocked when the hold is released or expires, and stays locked until delivered when it is executed.
+// Settling only writes world state so that the notary may settle the holds of any organization
+func settleHold(ctx contractapi.TransactionContextInterface, hold *CryptoMotionCoinHold, status CryptoMotionCoinHoldStatus) error {
+	if hold.CryptoMotionCoinID == "" {
+		recipient := hold.Payer
+		if status == HoldStatusExecuted {
+			recipient = hold.Payee
+		}
+
+		err := adjustBalance(ctx, recipient, hold.Amount)
+		if err != nil {
+			return err
+		}
+	} else if status != HoldStatusExecuted {
+		err := unlockCryptoMotionCoin(ctx, hold)
+		if err != nil {
+			return err
+		}
+	}
+
+	hold.Status = status
+
+	return putHold(ctx, hold)
+}
+
+func unlockCryptoMotionCoin(ctx contractapi.TransactionContextInterface, hold *CryptoMotionCoinHold) error {
+	assetHoldKey, err := getAssetHoldKey(ctx, hold.Collection, hold.CryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().DelState(assetHoldKey)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return nil
+}
+
+// deliverHeldCryptoMotionCoin makes the payee of a hold the owner and creator of the held CryptoMotionCoin, so that only the
+// payee may modify it from then on. It reads the collection of the
+// CryptoMotionCoin, so it must be endorsed by the peers of an organization that is a member of that collection
+func deliverHeldCryptoMotionCoin(ctx contractapi.TransactionContextInterface, hold *CryptoMotionCoinHold) error {
+	bytes, err := ctx.GetStub().GetPrivateData(hold.Collection, hold.CryptoMotionCoinID)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return cmcerrors.NotFoundf("The asset %s does not exist", hold.CryptoMotionCoinID)
+	}
+
+	existing, _, err := decodeCryptoMotionCoin(ctx, hold.Collection, bytes)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not unmarshal private data collection data to type CryptoMotionCoin. %w", err)
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	cryptoMotionCoin := *existing
+	cryptoMotionCoin.Owner = hold.Payee
+	cryptoMotionCoin.CreatorID = hold.PayeeID
+	cryptoMotionCoin.UpdatedAt = now
+
+	bytes, err = replaceCryptoMotionCoin(ctx, hold.Collection, hold.CryptoMotionCoinID, existing, &cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	return emitCryptoMotionCoinEvent(ctx, events.Updated{Payload: events.NewPayload(hold.CryptoMotionCoinID, hold.Collection, getAppraisalHash(bytes))})
+}
+
+// isHoldExpired reports whether a held hold is past its deadline
+func isHoldExpired(ctx contractapi.TransactionContextInterface, hold *CryptoMotionCoinHold) (bool, error) {
+	if hold.Status != HoldStatusHeld {
+		return false, nil
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return !now.Before(hold.Deadline), nil
+}
+
+// expireHoldIfDue expires a held hold whose deadline has passed and reports whether it did
+func expireHoldIfDue(ctx contractapi.TransactionContextInterface, hold *CryptoMotionCoinHold) (bool, error) {
+	expired, err := isHoldExpired(ctx, hold)
+	if err != nil || !expired {
+		return false, err
+	}
+
+	return true, settleHold(ctx, hold, HoldStatusExpired)
+}
+
+// checkNotHeld checks the CryptoMotionCoin held in a collection is not locked by a hold without writing anything. It
+// returns the hold when its deadline has passed, which the caller expires before changing the CryptoMotionCoin. A
+// CryptoMotionCoin stays locked after its hold is executed until it is delivered to the payee
+func checkNotHeld(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (*CryptoMotionCoinHold, error) {
+	assetHoldKey, err := getAssetHoldKey(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	}
+
+	holdID, err := ctx.GetStub().GetState(assetHoldKey)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if holdID == nil {
+		return nil, nil
+	}
+
+	hold, err := getHold(ctx, string(holdID))
+	if err != nil || hold == nil {
+		return nil, err
+	} else if hold.Status == HoldStatusExecuted {
+		return nil, cmcerrors.InvalidInputf("The asset %s must be delivered to the payee of hold %s", cryptoMotionCoinID, hold.ID)
+	}
+
+	expired, err := isHoldExpired(ctx, hold)
+	if err != nil {
+		return nil, err
+	} else if !expired {
+		return nil, cmcerrors.InvalidInputf("The asset %s is locked by hold %s until %s", cryptoMotionCoinID, hold.ID, hold.Deadline.Format(time.RFC3339))
+	}
+
+	return hold, nil
+}
+
+// assertNotHeld checks the CryptoMotionCoin held in a collection is not locked by a hold, expiring the hold when its
+// deadline has passed
+func assertNotHeld(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) error {
+	hold, err := checkNotHeld(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil || hold == nil {
+		return err
+	}
+
+	return settleHold(ctx, hold, HoldStatusExpired)
+}
+
+// readHold returns a hold, or a NotFound error when it does not exist
+func readHold(ctx contractapi.TransactionContextInterface, holdID string) (*CryptoMotionCoinHold, error) {
+	hold, err := getHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	} else if hold == nil {
+		return nil, cmcerrors.NotFoundf("The hold %s does not exist", holdID)
+	}
+
+	return hold, nil
+}
+
+// getSettleableHold returns a hold the client may settle as its notary. A hold past its deadline can only expire
+func getSettleableHold(ctx contractapi.TransactionContextInterface, holdID string, action string) (*CryptoMotionCoinHold, error) {
+	hold, err := readHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if hold.Status != HoldStatusHeld {
+		return nil, cmcerrors.InvalidInputf("The hold %s is already %s", holdID, hold.Status)
+	}
+
+	expired, err := isHoldExpired(ctx, hold)
+	if err != nil {
+		return nil, err
+	} else if expired {
+		return nil, cmcerrors.InvalidInputf("The hold %s expired at %s and can no longer be %s", holdID, hold.Deadline.Format(time.RFC3339), action)
+	}
+
+	clientID, err := getClientAccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientID != hold.Notary {
+		return nil, cmcerrors.Unauthorizedf("Access denied. Only the notary of hold %s may %s it", holdID, strings.TrimSuffix(action, "d"))
+	}
+
+	return hold, nil
+}
+
+// HoldCryptoMotionCoin locks either an amount of the client's balance or one of its CryptoMotionCoins for a payee. The deadline is
+// the transaction time plus the timeout, a duration such as 90m or 24h. Only the notary, a client account ID, may execute or
+// release the hold before the deadline. The payee of a CryptoMotionCoin must be a client account ID as well
+func (c *CryptoMotionCoinContract) HoldCryptoMotionCoin(ctx contractapi.TransactionContextInterface, holdID string, cryptoMotionCoinID string, amount int64, payee string, notary string, timeout string) (*CryptoMotionCoinHold, error) {
+	err := authorize(ctx, "HoldCryptoMotionCoin")
+	if err != nil {
+		return nil, err
+	}
+
+	if holdID == "" {
+		return nil, cmcerrors.InvalidInputf("The hold ID must be specified")
+	} else if payee == "" {
+		return nil, cmcerrors.InvalidInputf("The payee must be specified")
+	} else if notary == "" {
+		return nil, cmcerrors.InvalidInputf("The notary must be specified")
+	} else if (cryptoMotionCoinID == "") == (amount == 0) {
+		return nil, cmcerrors.InvalidInputf("Either an asset or an amount must be held")
+	}
+
+	existing, err := getHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, cmcerrors.AlreadyExistsf("The hold %s already exists", holdID)
+	}
+
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, cmcerrors.InvalidInputf("The timeout %s is not valid. It must be a duration such as 90m or 24h", timeout)
+	} else if duration <= 0 {
+		return nil, cmcerrors.InvalidInputf("The timeout %s must be positive", timeout)
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payer, err := getClientAccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &CryptoMotionCoinHold{
+		ID:       holdID,
+		Payer:    payer,
+		Payee:    payee,
+		Notary:   notary,
+		Deadline: now.Add(duration),
+		Status:   HoldStatusHeld,
+	}
+
+	if amount != 0 {
+		err = validateAmount(amount)
+		if err != nil {
+			return nil, err
+		}
+
+		err = adjustBalance(ctx, payer, -amount)
+		if err != nil {
+			return nil, err
+		}
+
+		hold.Amount = amount
+	} else {
+		err = c.lockCryptoMotionCoin(ctx, hold, cryptoMotionCoinID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = putHold(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// lockCryptoMotionCoin records the CryptoMotionCoin a hold locks. Locking requires the rights to update the CryptoMotionCoin
+func (c *CryptoMotionCoinContract) lockCryptoMotionCoin(ctx contractapi.TransactionContextInterface, hold *CryptoMotionCoinHold, cryptoMotionCoinID string) error {
+	err := authorize(ctx, "UpdateCryptoMotionCoin")
+	if err != nil {
+		return err
+	}
+
+	cryptoMotionCoin, err := c.ReadCryptoMotionCoin(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = assertCreator(ctx, cryptoMotionCoinID, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotHeld(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	assetHoldKey, err := getAssetHoldKey(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(assetHoldKey, []byte(hold.ID))
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	_, payeeID, ok := splitAccountID(hold.Payee)
+	if !ok {
+		return cmcerrors.InvalidInputf("The payee %s of an asset must be a client account ID such as Org1MSP:x509::CN=user1", hold.Payee)
+	}
+
+	hold.CryptoMotionCoinID = cryptoMotionCoinID
+	hold.Collection = collectionName
+	hold.PayeeID = payeeID
+
+	return nil
+}
+
+// ReleaseHold cancels a hold, returning what it locks to the payer. Holds past their deadline cannot be released and
+// expire instead
+func (c *CryptoMotionCoinContract) ReleaseHold(ctx contractapi.TransactionContextInterface, holdID string) (*CryptoMotionCoinHold, error) {
+	err := authorize(ctx, "ReleaseHold")
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := getSettleableHold(ctx, holdID, "released")
+	if err != nil {
+		return nil, err
+	}
+
+	err = settleHold(ctx, hold, HoldStatusReleased)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// ExecuteHold completes a hold, paying a held amount to the payee. A held CryptoMotionCoin stays locked until DeliverHold
+// makes the payee its owner, since only the peers of the organizations holding it may write it. Holds past their
+// deadline cannot be executed and expire instead
+func (c *CryptoMotionCoinContract) ExecuteHold(ctx contractapi.TransactionContextInterface, holdID string) (*CryptoMotionCoinHold, error) {
+	err := authorize(ctx, "ExecuteHold")
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := getSettleableHold(ctx, holdID, "executed")
+	if err != nil {
+		return nil, err
+	}
+
+	err = settleHold(ctx, hold, HoldStatusExecuted)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// DeliverHold makes the payee of an executed hold the owner and creator of the held CryptoMotionCoin and unlocks it. It must be endorsed
+// by the peers of the organization holding the CryptoMotionCoin, and may be submitted by any issuer or operator once the notary executed the hold
+func (c *CryptoMotionCoinContract) DeliverHold(ctx contractapi.TransactionContextInterface, holdID string) (*CryptoMotionCoinHold, error) {
+	err := authorize(ctx, "DeliverHold")
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := readHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	} else if hold.CryptoMotionCoinID == "" || hold.Status != HoldStatusExecuted || hold.Delivered {
+		return nil, cmcerrors.InvalidInputf("The hold %s has no asset awaiting delivery", holdID)
+	}
+
+	err = deliverHeldCryptoMotionCoin(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	err = unlockCryptoMotionCoin(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	hold.Delivered = true
+
+	err = putHold(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// ExpireHold returns what a hold past its deadline locks to the payer. Any issuer or operator may expire a hold once its deadline has passed
+func (c *CryptoMotionCoinContract) ExpireHold(ctx contractapi.TransactionContextInterface, holdID string) (*CryptoMotionCoinHold, error) {
+	err := authorize(ctx, "ExpireHold")
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := readHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	} else if hold.Status != HoldStatusHeld {
+		return nil, cmcerrors.InvalidInputf("The hold %s is already %s", holdID, hold.Status)
+	}
+
+	expired, err := expireHoldIfDue(ctx, hold)
+	if err != nil {
+		return nil, err
+	} else if !expired {
+		return nil, cmcerrors.InvalidInputf("The hold %s does not expire before %s", holdID, hold.Deadline.Format(time.RFC3339))
+	}
+
+	return hold, nil
+}
+
+// ReadHold retrieves a hold. A held hold past its deadline is reported as expired, although it is only settled by the
+// next transaction writing it
+func (c *CryptoMotionCoinContract) ReadHold(ctx contractapi.TransactionContextInterface, holdID string) (*CryptoMotionCoinHold, error) {
+	hold, err := readHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	expired, err := isHoldExpired(ctx, hold)
+	if err != nil {
+		return nil, err
+	} else if expired {
+		hold.Status = HoldStatusExpired
+	}
+
+	return hold, nil
+}
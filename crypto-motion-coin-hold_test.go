@@ -0,0 +1,253 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+const notaryAccount = "Org1MSP:x509::CN=notary"
+
+func configureAssetHolds(ms *MockStub, cryptoMotionCoinIDs ...string) {
+	var nilBytes []byte
+
+	for _, collectionName := range []string{"_implicit_org_Org1MSP", "_implicit_org_Org2MSP"} {
+		for _, cryptoMotionCoinID := range cryptoMotionCoinIDs {
+			assetHoldKey, _ := ms.CreateCompositeKey(assetHoldObjectType, []string{collectionName, cryptoMotionCoinID})
+			ms.On("GetState", assetHoldKey).Return(nilBytes, nil)
+		}
+	}
+}
+
+func configureHoldStub(holds ...*CryptoMotionCoinHold) (*MockContext, *MockStub) {
+	var nilBytes []byte
+
+	ctx, ms := configureStub()
+
+	for _, holdID := range []string{"newhold", "unknownhold"} {
+		holdKey, _ := ms.CreateCompositeKey(holdObjectType, []string{holdID})
+		ms.On("GetState", holdKey).Return(nilBytes, nil)
+	}
+
+	for _, hold := range holds {
+		holdKey, _ := ms.CreateCompositeKey(holdObjectType, []string{hold.ID})
+		holdBytes, _ := canonicalJSON(hold)
+		ms.On("GetState", holdKey).Return(holdBytes, nil)
+	}
+
+	ms.On("GetState", getBalanceKeyOf(ms, clientAccount)).Return([]byte("100"), nil)
+	ms.On("GetState", getBalanceKeyOf(ms, "payee")).Return(nilBytes, nil)
+
+	return ctx, ms
+}
+
+func getBalanceKeyOf(ms *MockStub, account string) string {
+	balanceKey, _ := ms.CreateCompositeKey(balanceObjectType, []string{account})
+	return balanceKey
+}
+
+func newTestHold(id string, deadline time.Time) *CryptoMotionCoinHold {
+	return &CryptoMotionCoinHold{ID: id, Payer: clientAccount, Payee: "payee", Notary: notaryAccount, Amount: 30, Deadline: deadline, Status: HoldStatusHeld}
+}
+
+func TestHoldCryptoMotionCoin(t *testing.T) {
+	var hold *CryptoMotionCoinHold
+	var err error
+
+	ctx, stub := configureHoldStub(newTestHold("amounthold", txTime.Add(time.Hour)))
+	c := new(CryptoMotionCoinContract)
+
+	_, err = c.HoldCryptoMotionCoin(ctx, "newhold", "", 0, "payee", notaryAccount, "1h")
+	assert.EqualError(t, err, "[INVALID_INPUT] Either an asset or an amount must be held", "should error when nothing is held")
+
+	_, err = c.HoldCryptoMotionCoin(ctx, "newhold", "cryptoMotionCoinkey", 10, "payee", notaryAccount, "1h")
+	assert.EqualError(t, err, "[INVALID_INPUT] Either an asset or an amount must be held", "should error when both an asset and an amount are held")
+
+	_, err = c.HoldCryptoMotionCoin(ctx, "amounthold", "", 10, "payee", notaryAccount, "1h")
+	assert.EqualError(t, err, "[ALREADY_EXISTS] The hold amounthold already exists", "should error when the hold exists")
+
+	_, err = c.HoldCryptoMotionCoin(ctx, "newhold", "", 10, "payee", notaryAccount, "tomorrow")
+	assert.EqualError(t, err, "[INVALID_INPUT] The timeout tomorrow is not valid. It must be a duration such as 90m or 24h", "should error when the timeout cannot be parsed")
+
+	_, err = c.HoldCryptoMotionCoin(ctx, "newhold", "", 10, "payee", notaryAccount, "-5m")
+	assert.EqualError(t, err, "[INVALID_INPUT] The timeout -5m must be positive", "should error when the timeout is not positive")
+
+	_, err = c.HoldCryptoMotionCoin(ctx, "newhold", "", 500, "payee", notaryAccount, "1h")
+	assert.EqualError(t, err, "[INVALID_INPUT] Insufficient funds. Cannot subtract 500 from 100", "should error when the balance is too low")
+
+	hold, err = c.HoldCryptoMotionCoin(ctx, "newhold", "", 30, "payee", notaryAccount, "1h")
+	assert.Nil(t, err, "should not return error when holding an amount")
+	assert.Equal(t, &CryptoMotionCoinHold{ID: "newhold", Payer: clientAccount, Payee: "payee", Notary: notaryAccount, Amount: 30, Deadline: txTime.Add(time.Hour), Status: HoldStatusHeld}, hold)
+	stub.AssertCalled(t, "PutState", getBalanceKeyOf(stub, clientAccount), []byte("70"))
+
+	_, err = c.HoldCryptoMotionCoin(ctx, "newhold", "cryptoMotionCoinkey", 0, "user2", notaryAccount, "1h")
+	assert.EqualError(t, err, "[INVALID_INPUT] The payee user2 of an asset must be a client account ID such as Org1MSP:x509::CN=user1", "should error when the payee of an asset is not a client account")
+
+	hold, err = c.HoldCryptoMotionCoin(ctx, "newhold", "cryptoMotionCoinkey", 0, "Org1MSP:x509::CN=user2", notaryAccount, "1h")
+	assert.Nil(t, err, "should not return error when holding an asset")
+	assert.Equal(t, "_implicit_org_Org1MSP", hold.Collection)
+	assert.Equal(t, "x509::CN=user2", hold.PayeeID, "should record the client ID of the payee")
+	assetHoldKey, _ := stub.CreateCompositeKey(assetHoldObjectType, []string{"_implicit_org_Org1MSP", "cryptoMotionCoinkey"})
+	stub.AssertCalled(t, "PutState", assetHoldKey, []byte("newhold"))
+}
+
+func TestSettleAmountHold(t *testing.T) {
+	var hold *CryptoMotionCoinHold
+	var err error
+
+	ctx, stub := configureHoldStub(newTestHold("amounthold", txTime.Add(time.Hour)), newTestHold("expiredhold", txTime), &CryptoMotionCoinHold{ID: "releasedhold", Status: HoldStatusReleased})
+	c := new(CryptoMotionCoinContract)
+
+	_, err = c.ExecuteHold(ctx, "unknownhold")
+	assert.EqualError(t, err, "[NOT_FOUND] The hold unknownhold does not exist", "should error when the hold does not exist")
+
+	_, err = c.ExecuteHold(ctx, "releasedhold")
+	assert.EqualError(t, err, "[INVALID_INPUT] The hold releasedhold is already RELEASED", "should error when the hold was settled")
+
+	_, err = c.ExecuteHold(ctx, "amounthold")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. Only the notary of hold amounthold may execute it", "should error when the client is not the notary")
+
+	_, err = c.ReleaseHold(ctx, "amounthold")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. Only the notary of hold amounthold may release it", "should error when the client is not the notary")
+
+	_, err = c.ExpireHold(ctx, "amounthold")
+	assert.EqualError(t, err, "[INVALID_INPUT] The hold amounthold does not expire before 2020-06-01T13:00:00Z", "should error when expiring a hold before its deadline")
+
+	_, err = c.ExecuteHold(ctx, "expiredhold")
+	assert.EqualError(t, err, "[INVALID_INPUT] The hold expiredhold expired at 2020-06-01T12:00:00Z and can no longer be executed", "should error when executing after the deadline")
+
+	_, err = c.ReleaseHold(ctx, "expiredhold")
+	assert.EqualError(t, err, "[INVALID_INPUT] The hold expiredhold expired at 2020-06-01T12:00:00Z and can no longer be released", "should error when releasing after the deadline")
+	stub.AssertNotCalled(t, "PutState", getBalanceKeyOf(stub, clientAccount), []byte("130"))
+
+	hold, err = c.ReadHold(ctx, "expiredhold")
+	assert.Nil(t, err, "should not return error when reading an expired hold")
+	assert.Equal(t, HoldStatusExpired, hold.Status, "should report the hold as expired after the deadline")
+	stub.AssertNotCalled(t, "PutState", getBalanceKeyOf(stub, clientAccount), []byte("130"))
+
+	hold, err = c.ExpireHold(ctx, "expiredhold")
+	assert.Nil(t, err, "should not return error when expiring after the deadline")
+	assert.Equal(t, HoldStatusExpired, hold.Status)
+	stub.AssertCalled(t, "PutState", getBalanceKeyOf(stub, clientAccount), []byte("130"))
+
+	setClientID(ctx, "x509::CN=notary")
+
+	hold, err = c.ExecuteHold(ctx, "amounthold")
+	assert.Nil(t, err, "should not return error when the notary executes")
+	assert.Equal(t, HoldStatusExecuted, hold.Status)
+	stub.AssertCalled(t, "PutState", getBalanceKeyOf(stub, "payee"), []byte("30"))
+}
+
+func TestSettleAssetHold(t *testing.T) {
+	var hold *CryptoMotionCoinHold
+	var err error
+
+	assetHold := &CryptoMotionCoinHold{ID: "assethold", Payer: clientAccount, Payee: "user2", Notary: clientAccount, CryptoMotionCoinID: "heldkey", Collection: "_implicit_org_Org1MSP", Deadline: txTime.Add(time.Hour), Status: HoldStatusHeld}
+	executedHold := &CryptoMotionCoinHold{ID: "executedhold", Payer: clientAccount, Payee: "Org1MSP:x509::CN=user2", PayeeID: "x509::CN=user2", Notary: notaryAccount, CryptoMotionCoinID: "heldkey", Collection: "_implicit_org_Org1MSP", Deadline: txTime.Add(time.Hour), Status: HoldStatusExecuted}
+	ctx, stub := configureHoldStub(assetHold, executedHold, newTestHold("amounthold", txTime.Add(time.Hour)))
+	c := new(CryptoMotionCoinContract)
+
+	stored, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
+	assetHoldKey, _ := stub.CreateCompositeKey(assetHoldObjectType, []string{"_implicit_org_Org1MSP", "heldkey"})
+	stub.On("GetState", assetHoldKey).Return([]byte("assethold"), nil)
+	stub.On("GetPrivateDataHash", "_implicit_org_Org1MSP", "heldkey").Return([]byte("some hash value"), nil)
+	stub.On("GetPrivateData", "_implicit_org_Org1MSP", "heldkey").Return(stored, nil)
+	stub.On("GetState", publicKey(stub, "_implicit_org_Org1MSP", "heldkey")).Return([]byte(nil), nil)
+	configureHistoryHeads(stub, "heldkey")
+
+	err = c.DeleteCryptoMotionCoin(ctx, "heldkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The asset heldkey is locked by hold assethold until 2020-06-01T13:00:00Z", "should error when modifying a held asset")
+
+	hold, err = c.ExecuteHold(ctx, "assethold")
+	assert.Nil(t, err, "should not return error when the notary executes")
+	assert.Equal(t, HoldStatusExecuted, hold.Status)
+	stub.AssertNotCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "heldkey", mock.Anything)
+	stub.AssertNotCalled(t, "DelState", assetHoldKey)
+
+	_, err = c.DeliverHold(ctx, "amounthold")
+	assert.EqualError(t, err, "[INVALID_INPUT] The hold amounthold has no asset awaiting delivery", "should error when the hold does not hold an asset")
+
+	_, err = c.DeliverHold(ctx, "assethold")
+	assert.EqualError(t, err, "[INVALID_INPUT] The hold assethold has no asset awaiting delivery", "should error when the hold was not executed")
+
+	hold, err = c.DeliverHold(ctx, "executedhold")
+	assert.Nil(t, err, "should not return error when delivering an executed hold")
+	assert.True(t, hold.Delivered)
+	delivered := newTestCryptoMotionCoin()
+	delivered.Owner = "Org1MSP:x509::CN=user2"
+	delivered.CreatorID = "x509::CN=user2"
+	delivered.UpdatedAt = txTime
+	deliveredBytes, _ := encodeCryptoMotionCoin(delivered)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "heldkey", deliveredBytes)
+	stub.AssertCalled(t, "DelState", assetHoldKey)
+}
+
+func TestHoldDispatch(t *testing.T) {
+	var hold CryptoMotionCoinHold
+
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	notary := newScenarioIdentity(t, "Org2MSP", "notary2", roleOperator)
+	payee := newScenarioIdentity(t, "Org1MSP", "payee1", roleIssuer)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+
+	invokeScenario(t, ledger, chaincode, issuer, transient, "CreateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, issuer, nil, "HoldCryptoMotionCoin", "hold1", "coin1", "0", getScenarioAccountID(t, payee), getScenarioAccountID(t, notary), "1h")
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, notary, nil, "ExecuteHold", "hold1")), &hold))
+	assert.Equal(t, HoldStatusExecuted, hold.Status, "should let the notary of another organization execute the hold")
+	assert.False(t, hold.Delivered)
+
+	cryptoMotionCoin, err := readScenarioCryptoMotionCoin(ledger, issuer, "coin1")
+	require.NoError(t, err)
+	assert.Equal(t, "user1", cryptoMotionCoin.Owner, "should not write the collection of the holder when executing")
+
+	response := ledger.Invoke(chaincode, issuer, transient, "UpdateCryptoMotionCoin", "coin1")
+	assert.Equal(t, "[INVALID_INPUT] The asset coin1 must be delivered to the payee of hold hold1", response.Message, "should keep the asset locked until delivery")
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "DeliverHold", "hold1")), &hold))
+	assert.True(t, hold.Delivered)
+
+	cryptoMotionCoin, err = readScenarioCryptoMotionCoin(ledger, issuer, "coin1")
+	require.NoError(t, err)
+	assert.Equal(t, getScenarioAccountID(t, payee), cryptoMotionCoin.Owner, "should deliver the asset to the payee")
+
+	response = ledger.Invoke(chaincode, issuer, nil, "DeliverHold", "hold1")
+	assert.Equal(t, "[INVALID_INPUT] The hold hold1 has no asset awaiting delivery", response.Message, "should deliver the asset once")
+
+	response = ledger.Invoke(chaincode, issuer, transient, "UpdateCryptoMotionCoin", "coin1")
+	assert.Equal(t, "[UNAUTHORIZED] Access denied. Only the creator of asset coin1 or an admin may modify it", response.Message, "should not let the payer modify the delivered asset")
+
+	invokeScenario(t, ledger, chaincode, payee, transient, "UpdateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, payee, nil, "HoldCryptoMotionCoin", "hold2", "coin1", "0", getScenarioAccountID(t, issuer), getScenarioAccountID(t, notary), "1h")
+	ledger.Advance(time.Hour)
+
+	response = ledger.Invoke(chaincode, notary, nil, "ExecuteHold", "hold2")
+	assert.Regexp(t, `^\[INVALID_INPUT\] The hold hold2 expired at .+ and can no longer be executed$`, response.Message, "should not execute the hold after its deadline")
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, notary, nil, "ReadHold", "hold2")), &hold))
+	assert.Equal(t, HoldStatusExpired, hold.Status, "should report the hold as expired")
+
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, notary, nil, "ExpireHold", "hold2")), &hold))
+	assert.Equal(t, HoldStatusExpired, hold.Status)
+
+	invokeScenario(t, ledger, chaincode, payee, transient, "UpdateCryptoMotionCoin", "coin1")
+
+	var amountHold CryptoMotionCoinHold
+
+	invokeScenario(t, ledger, chaincode, issuer, nil, "Mint", "100")
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "HoldCryptoMotionCoin", "hold3", "", "10", "payee", getScenarioAccountID(t, notary), "1h")), &amountHold))
+	assert.Equal(t, int64(10), amountHold.Amount, "should return amount holds without an asset")
+	assert.Equal(t, "", amountHold.CryptoMotionCoinID)
+}
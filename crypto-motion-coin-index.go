@@ -0,0 +1,177 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// Secondary indexes are stored as composite keys alongside the CryptoMotionCoins in the same collection.
+// Composite keys are never returned by range queries so they do not show up when listing CryptoMotionCoins
+const (
+	ownerIndexName  = "owner~id"
+	statusIndexName = "status~id"
+)
+
+// indexValue is stored under every index key since private data cannot hold an empty value
+var indexValue = []byte{0x00}
+
+// getCryptoMotionCoinIndexKeys returns the index keys of a CryptoMotionCoin
+func getCryptoMotionCoinIndexKeys(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string, cryptoMotionCoin *CryptoMotionCoin) ([]string, error) {
+	ownerKey, err := createCompositeKey(ctx, ownerIndexName, []string{cryptoMotionCoin.Owner, cryptoMotionCoinID})
+	if err != nil {
+		return nil, err
+	}
+
+	statusKey, err := createCompositeKey(ctx, statusIndexName, []string{string(cryptoMotionCoin.Status), cryptoMotionCoinID})
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{ownerKey, statusKey}, nil
+}
+
+// putCryptoMotionCoinIndexes writes the index keys of a CryptoMotionCoin
+func putCryptoMotionCoinIndexes(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, cryptoMotionCoin *CryptoMotionCoin) error {
+	indexKeys, err := getCryptoMotionCoinIndexKeys(ctx, cryptoMotionCoinID, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	for _, indexKey := range indexKeys {
+		err = ctx.GetStub().PutPrivateData(collectionName, indexKey, indexValue)
+		if err != nil {
+			return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+		}
+	}
+
+	return nil
+}
+
+// delCryptoMotionCoinIndexes removes the index keys of a CryptoMotionCoin
+func delCryptoMotionCoinIndexes(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, cryptoMotionCoin *CryptoMotionCoin) error {
+	indexKeys, err := getCryptoMotionCoinIndexKeys(ctx, cryptoMotionCoinID, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	for _, indexKey := range indexKeys {
+		err = ctx.GetStub().DelPrivateData(collectionName, indexKey)
+		if err != nil {
+			return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+		}
+	}
+
+	return nil
+}
+
+// updateCryptoMotionCoinIndexes replaces the index keys that changed between two versions of a CryptoMotionCoin
+func updateCryptoMotionCoinIndexes(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, existing *CryptoMotionCoin, cryptoMotionCoin *CryptoMotionCoin) error {
+	existingKeys, err := getCryptoMotionCoinIndexKeys(ctx, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
+	}
+
+	indexKeys, err := getCryptoMotionCoinIndexKeys(ctx, cryptoMotionCoinID, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	for i := range indexKeys {
+		if existingKeys[i] == indexKeys[i] {
+			continue
+		}
+
+		err = ctx.GetStub().DelPrivateData(collectionName, existingKeys[i])
+		if err != nil {
+			return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+		}
+
+		err = ctx.GetStub().PutPrivateData(collectionName, indexKeys[i], indexValue)
+		if err != nil {
+			return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+		}
+	}
+
+	return nil
+}
+
+// queryCryptoMotionCoinIndex reads the CryptoMotionCoins whose index entries start with the given value
+func queryCryptoMotionCoinIndex(ctx contractapi.TransactionContextInterface, indexName string, value string) ([]CryptoMotionCoinRecord, error) {
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(collectionName, indexName, []string{value})
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []CryptoMotionCoinRecord{}
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResult.Key)
+		if err != nil || len(attributes) != 2 {
+			return nil, cmcerrors.LedgerErrorf("The index key %q is not valid", queryResult.Key)
+		}
+
+		cryptoMotionCoinID := attributes[1]
+
+		bytes, err := ctx.GetStub().GetPrivateData(collectionName, cryptoMotionCoinID)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		} else if bytes == nil {
+			return nil, cmcerrors.LedgerErrorf("The index entry for asset %s is stale. The asset does not exist", cryptoMotionCoinID)
+		}
+
+		cryptoMotionCoin, _, err := decodeCryptoMotionCoin(ctx, collectionName, bytes)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not unmarshal private data collection data for asset %s. %w", cryptoMotionCoinID, err)
+		}
+
+		records = append(records, CryptoMotionCoinRecord{ID: cryptoMotionCoinID, Asset: cryptoMotionCoin})
+	}
+
+	return records, nil
+}
+
+// QueryCryptoMotionCoinsByOwner returns the CryptoMotionCoins held by an owner using the owner index
+func (c *CryptoMotionCoinContract) QueryCryptoMotionCoinsByOwner(ctx contractapi.TransactionContextInterface, owner string) ([]CryptoMotionCoinRecord, error) {
+	err := authorize(ctx, "QueryCryptoMotionCoinsByOwner")
+	if err != nil {
+		return nil, err
+	}
+
+	if owner == "" {
+		return nil, cmcerrors.InvalidInputf("The owner must be specified")
+	}
+
+	return queryCryptoMotionCoinIndex(ctx, ownerIndexName, owner)
+}
+
+// QueryCryptoMotionCoinsByStatus returns the CryptoMotionCoins with a status using the status index
+func (c *CryptoMotionCoinContract) QueryCryptoMotionCoinsByStatus(ctx contractapi.TransactionContextInterface, status string) ([]CryptoMotionCoinRecord, error) {
+	err := authorize(ctx, "QueryCryptoMotionCoinsByStatus")
+	if err != nil {
+		return nil, err
+	}
+
+	switch CryptoMotionCoinStatus(status) {
+	case StatusActive, StatusFrozen, StatusRedeemed:
+	default:
+		return nil, cmcerrors.InvalidInputf("The status %s is not valid. It must be one of ACTIVE, FROZEN or REDEEMED", status)
+	}
+
+	return queryCryptoMotionCoinIndex(ctx, statusIndexName, status)
+}
@@ -0,0 +1,121 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func indexKey(stub *MockStub, indexName string, value string, cryptoMotionCoinID string) string {
+	key, _ := stub.CreateCompositeKey(indexName, []string{value, cryptoMotionCoinID})
+	return key
+}
+
+func TestCreateCryptoMotionCoinIndexes(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user2","amount":50,"denomination":"CMC"}`)
+	err := c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.Nil(t, err, "should not return error when creating")
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user2", "missingkey"), indexValue)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", indexKey(stub, statusIndexName, "ACTIVE", "missingkey"), indexValue)
+}
+
+func TestUpdateCryptoMotionCoinIndexes(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user1","amount":75,"denomination":"CMC"}`)
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when updating")
+	stub.AssertNotCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"))
+	stub.AssertNotCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"), mock.Anything)
+	stub.AssertNotCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", indexKey(stub, statusIndexName, "ACTIVE", "cryptoMotionCoinkey"), mock.Anything)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user3","amount":75,"denomination":"CMC","status":"FROZEN"}`)
+	err = c.UpdateCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when updating the owner and status")
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"))
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user3", "cryptoMotionCoinkey"), indexValue)
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", indexKey(stub, statusIndexName, "ACTIVE", "cryptoMotionCoinkey"))
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", indexKey(stub, statusIndexName, "FROZEN", "cryptoMotionCoinkey"), indexValue)
+}
+
+func TestDeleteCryptoMotionCoinIndexes(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	err := c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when deleting")
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"))
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", indexKey(stub, statusIndexName, "ACTIVE", "cryptoMotionCoinkey"))
+}
+
+func TestTransferCryptoMotionCoinIndexes(t *testing.T) {
+	ctx, stub := configureTransferStub()
+	c := new(CryptoMotionCoinContract)
+
+	err := c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org2MSP")
+	assert.Nil(t, err, "should not return error when transferring")
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org2MSP", indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"), indexValue)
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"))
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", indexKey(stub, statusIndexName, "ACTIVE", "cryptoMotionCoinkey"))
+}
+
+func TestQueryCryptoMotionCoinsByOwner(t *testing.T) {
+	var records []CryptoMotionCoinRecord
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stub.On("GetPrivateDataByPartialCompositeKey", "_implicit_org_Org1MSP", ownerIndexName, []string{"user1"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"), Value: indexValue},
+	}}, nil)
+	stub.On("GetPrivateDataByPartialCompositeKey", "_implicit_org_Org1MSP", ownerIndexName, []string{"user2"}).Return(&MockIterator{}, nil)
+	stub.On("GetPrivateDataByPartialCompositeKey", "_implicit_org_Org1MSP", ownerIndexName, []string{"stale"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: indexKey(stub, ownerIndexName, "stale", "missingkey"), Value: indexValue},
+	}}, nil)
+
+	_, err = c.QueryCryptoMotionCoinsByOwner(ctx, "")
+	assert.EqualError(t, err, "[INVALID_INPUT] The owner must be specified", "should error when no owner is given")
+
+	records, err = c.QueryCryptoMotionCoinsByOwner(ctx, "user1")
+	assert.Nil(t, err, "should not return error when the owner holds assets")
+	assert.Equal(t, []CryptoMotionCoinRecord{{ID: "cryptoMotionCoinkey", Asset: newTestCryptoMotionCoin()}}, records, "should return the indexed assets")
+
+	records, err = c.QueryCryptoMotionCoinsByOwner(ctx, "user2")
+	assert.Nil(t, err, "should not return error when the owner holds no assets")
+	assert.Equal(t, []CryptoMotionCoinRecord{}, records, "should return an empty list when the owner holds no assets")
+
+	_, err = c.QueryCryptoMotionCoinsByOwner(ctx, "stale")
+	assert.EqualError(t, err, "[LEDGER_ERROR] The index entry for asset missingkey is stale. The asset does not exist", "should error when the index points at a missing asset")
+}
+
+func TestQueryCryptoMotionCoinsByStatus(t *testing.T) {
+	var records []CryptoMotionCoinRecord
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stub.On("GetPrivateDataByPartialCompositeKey", "_implicit_org_Org1MSP", statusIndexName, []string{"ACTIVE"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: indexKey(stub, statusIndexName, "ACTIVE", "cryptoMotionCoinkey"), Value: indexValue},
+	}}, nil)
+
+	_, err = c.QueryCryptoMotionCoinsByStatus(ctx, "LOST")
+	assert.EqualError(t, err, "[INVALID_INPUT] The status LOST is not valid. It must be one of ACTIVE, FROZEN or REDEEMED", "should error when the status is unknown")
+
+	records, err = c.QueryCryptoMotionCoinsByStatus(ctx, "ACTIVE")
+	assert.Nil(t, err, "should not return error when assets have the status")
+	assert.Equal(t, []CryptoMotionCoinRecord{{ID: "cryptoMotionCoinkey", Asset: newTestCryptoMotionCoin()}}, records, "should return the indexed assets")
+}
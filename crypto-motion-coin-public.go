@@ -0,0 +1,186 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// publicObjectType is the composite key object type of the world state records holding the public fields of a CryptoMotionCoin
+const publicObjectType = "public"
+
+// publicTransientKey is the transient data key that, when set to "true" on creation, also records the public fields of a CryptoMotionCoin in world state
+const publicTransientKey = "public"
+
+// CryptoMotionCoinPublic holds the fields of a CryptoMotionCoin every organization may see
+type CryptoMotionCoinPublic struct {
+	ID            string                 `json:"id"`
+	OwnerMSP      string                 `json:"ownerMSP"`
+	Status        CryptoMotionCoinStatus `json:"status"`
+	AppraisalHash string                 `json:"appraisalHash"`
+}
+
+// CryptoMotionCoinView merges the public record of a CryptoMotionCoin with its private fields when the client may read them
+type CryptoMotionCoinView struct {
+	Public     *CryptoMotionCoinPublic `json:"public,omitempty" metadata:"public,optional"`
+	Private    *CryptoMotionCoin       `json:"private,omitempty" metadata:"private,optional"`
+	Authorized bool                    `json:"authorized"`
+	Verified   bool                    `json:"verified"`
+}
+
+func getAppraisalHash(bytes []byte) string {
+	hash := sha256.Sum256(bytes)
+
+	return hex.EncodeToString(hash[:])
+}
+
+func isPublicRequested(ctx contractapi.TransactionContextInterface) (bool, error) {
+	transientData, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return false, cmcerrors.LedgerErrorf("Could not read transient data. %w", err)
+	}
+
+	return string(transientData[publicTransientKey]) == "true", nil
+}
+
+func getPublicKey(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (string, error) {
+	return createCollectionKey(ctx, publicObjectType, collectionName, cryptoMotionCoinID)
+}
+
+func getPublicCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (*CryptoMotionCoinPublic, error) {
+	publicKey, err := getPublicKey(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(publicKey)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return nil, nil
+	}
+
+	public := new(CryptoMotionCoinPublic)
+
+	err = json.Unmarshal(bytes, public)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not unmarshal world state data to type CryptoMotionCoinPublic. %w", err)
+	}
+
+	return public, nil
+}
+
+func putPublicCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, public *CryptoMotionCoinPublic) error {
+	publicKey, err := getPublicKey(ctx, collectionName, public.ID)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := canonicalJSON(public)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(publicKey, bytes)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return nil
+}
+
+func delPublicCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) error {
+	publicKey, err := getPublicKey(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().DelState(publicKey)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return nil
+}
+
+// refreshPublicCryptoMotionCoin keeps an existing public record in line with the private fields just written
+func refreshPublicCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string, cryptoMotionCoin *CryptoMotionCoin, privateBytes []byte) error {
+	public, err := getPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil || public == nil {
+		return err
+	}
+
+	public.Status = cryptoMotionCoin.Status
+	public.AppraisalHash = getAppraisalHash(privateBytes)
+
+	return putPublicCryptoMotionCoin(ctx, collectionName, public)
+}
+
+// ReadPublicCryptoMotionCoin retrieves the public record of the CryptoMotionCoin held in a collection from world state
+func (c *CryptoMotionCoinContract) ReadPublicCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (*CryptoMotionCoinPublic, error) {
+	public, err := getPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	} else if public == nil {
+		return nil, cmcerrors.NotFoundf("The asset %s does not have a public record in collection %s", cryptoMotionCoinID, collectionName)
+	}
+
+	return public, nil
+}
+
+// ReadCryptoMotionCoinView retrieves the public record of a CryptoMotionCoin merged with its private fields when the client may read them
+func (c *CryptoMotionCoinContract) ReadCryptoMotionCoinView(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) (*CryptoMotionCoinView, error) {
+	err := authorize(ctx, "ReadCryptoMotionCoinView")
+	if err != nil {
+		return nil, err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	public, err := getPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	}
+
+	authorized, err := c.CryptoMotionCoinExists(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	} else if public == nil && !authorized {
+		return nil, cmcerrors.NotFoundf("The asset %s does not exist", cryptoMotionCoinID)
+	}
+
+	view := new(CryptoMotionCoinView)
+	view.Public = public
+	view.Authorized = authorized
+
+	if !authorized {
+		return view, nil
+	}
+
+	view.Private, err = c.ReadCryptoMotionCoin(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return nil, err
+	}
+
+	if public != nil {
+		privateHash, err := ctx.GetStub().GetPrivateDataHash(collectionName, cryptoMotionCoinID)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		view.Verified = hex.EncodeToString(privateHash) == public.AppraisalHash
+	}
+
+	return view, nil
+}
@@ -0,0 +1,168 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+func publicKey(ms *MockStub, collectionName string, cryptoMotionCoinID string) string {
+	key, _ := ms.CreateCompositeKey(publicObjectType, []string{collectionName, cryptoMotionCoinID})
+
+	return key
+}
+
+func configurePublicStub(ms *MockStub, cryptoMotionCoinBytes []byte) {
+	var nilBytes []byte
+
+	hash := sha256.Sum256(cryptoMotionCoinBytes)
+
+	ms.On("GetPrivateData", mock.AnythingOfType("string"), "publickey").Return(cryptoMotionCoinBytes, nil)
+	ms.On("GetPrivateDataHash", mock.AnythingOfType("string"), "publickey").Return(hash[:], nil)
+	ms.On("GetState", publicKey(ms, "_implicit_org_Org1MSP", "publickey")).Return([]byte(`{"appraisalHash":"`+hex.EncodeToString(hash[:])+`","id":"publickey","ownerMSP":"Org1MSP","status":"ACTIVE"}`), nil)
+	ms.On("GetPrivateData", mock.AnythingOfType("string"), "publiconlykey").Return(nilBytes, nil)
+	ms.On("GetPrivateDataHash", mock.AnythingOfType("string"), "publiconlykey").Return(nilBytes, nil)
+	ms.On("GetState", publicKey(ms, "_implicit_org_Org2MSP", "publiconlykey")).Return([]byte(`{"appraisalHash":"abcd","id":"publiconlykey","ownerMSP":"Org2MSP","status":"FROZEN"}`), nil)
+
+	for _, cryptoMotionCoinID := range []string{"missingkey", "existingkey", "cryptoMotionCoinkey", "publiconlykey"} {
+		ms.On("GetState", publicKey(ms, "_implicit_org_Org1MSP", cryptoMotionCoinID)).Return(nilBytes, nil)
+	}
+}
+
+func TestCreatePublicCryptoMotionCoin(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user2","amount":50,"denomination":"CMC"}`)
+	transient[publicTransientKey] = []byte("true")
+
+	err = c.CreateCryptoMotionCoin(ctx, "publiconlykey")
+	assert.Nil(t, err, "should not return error when another organization published the same ID in its collection")
+	stub.AssertCalled(t, "PutState", publicKey(stub, "_implicit_org_Org1MSP", "publiconlykey"), mock.AnythingOfType("[]uint8"))
+	stub.AssertNotCalled(t, "PutState", publicKey(stub, "_implicit_org_Org2MSP", "publiconlykey"), mock.Anything)
+
+	err = c.CreateCryptoMotionCoin(ctx, "missingkey")
+	assert.Nil(t, err, "should not return error when creating a public asset")
+
+	privateBytes := []byte(`{"data":{"amount":50,"createdAt":"2020-06-01T12:00:00Z","creatorID":"x509::CN=user1","denomination":"CMC","issuerMSP":"Org1MSP","owner":"user2","status":"ACTIVE","updatedAt":"2020-06-01T12:00:00Z"},"schemaVersion":2}`)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "missingkey", privateBytes)
+	stub.AssertCalled(t, "PutState", publicKey(stub, "_implicit_org_Org1MSP", "missingkey"), []byte(`{"appraisalHash":"`+getAppraisalHash(privateBytes)+`","id":"missingkey","ownerMSP":"Org1MSP","status":"ACTIVE"}`))
+}
+
+func TestUpdatePublicCryptoMotionCoin(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[cryptoMotionCoinTransientKey] = []byte(`{"owner":"user1","amount":100,"denomination":"CMC","status":"FROZEN"}`)
+	err := c.UpdateCryptoMotionCoin(ctx, "publickey")
+	assert.Nil(t, err, "should not return error when updating a public asset")
+
+	expected := newTestCryptoMotionCoin()
+	expected.Status = StatusFrozen
+	expected.UpdatedAt = txTime
+	privateBytes, _ := encodeCryptoMotionCoin(expected)
+	stub.AssertCalled(t, "PutState", publicKey(stub, "_implicit_org_Org1MSP", "publickey"), []byte(`{"appraisalHash":"`+getAppraisalHash(privateBytes)+`","id":"publickey","ownerMSP":"Org1MSP","status":"FROZEN"}`))
+	stub.AssertNotCalled(t, "PutState", "cryptoMotionCoinkey", mock.Anything)
+}
+
+func TestDeletePublicCryptoMotionCoin(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	err := c.DeleteCryptoMotionCoin(ctx, "publickey")
+	assert.Nil(t, err, "should not return error when deleting a public asset")
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", "publickey")
+	stub.AssertCalled(t, "DelState", publicKey(stub, "_implicit_org_Org1MSP", "publickey"))
+
+	err = c.DeleteCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when deleting a private asset")
+	stub.AssertNotCalled(t, "DelState", publicKey(stub, "_implicit_org_Org1MSP", "cryptoMotionCoinkey"))
+}
+
+func TestReadPublicCryptoMotionCoin(t *testing.T) {
+	var public *CryptoMotionCoinPublic
+	var err error
+
+	ctx, _ := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	public, err = c.ReadPublicCryptoMotionCoin(ctx, "_implicit_org_Org1MSP", "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset cryptoMotionCoinkey does not have a public record in collection _implicit_org_Org1MSP", "should error when the asset is private only")
+	assert.Nil(t, public)
+
+	public, err = c.ReadPublicCryptoMotionCoin(ctx, "_implicit_org_Org1MSP", "publiconlykey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset publiconlykey does not have a public record in collection _implicit_org_Org1MSP", "should not read the public records of other collections")
+	assert.Nil(t, public)
+
+	public, err = c.ReadPublicCryptoMotionCoin(ctx, "_implicit_org_Org2MSP", "publiconlykey")
+	assert.Nil(t, err, "should not return error when the asset has a public record")
+	assert.Equal(t, &CryptoMotionCoinPublic{ID: "publiconlykey", OwnerMSP: "Org2MSP", Status: StatusFrozen, AppraisalHash: "abcd"}, public)
+}
+
+func TestReadCryptoMotionCoinView(t *testing.T) {
+	var view *CryptoMotionCoinView
+	var err error
+
+	ctx, _ := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	view, err = c.ReadCryptoMotionCoinView(ctx, "missingkey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when the asset is neither public nor readable")
+	assert.Nil(t, view)
+
+	view, err = c.ReadCryptoMotionCoinView(ctx, "publiconlykey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset publiconlykey does not exist", "should not merge the public records of other collections")
+	assert.Nil(t, view)
+
+	view, err = c.ReadCryptoMotionCoinView(ctx, "publickey")
+	assert.Nil(t, err, "should not return error when both records are visible")
+	assert.True(t, view.Authorized, "should be authorized for the private fields of the own organization")
+	assert.True(t, view.Verified, "should verify the private fields against the appraisal hash")
+	assert.Equal(t, newTestCryptoMotionCoin(), view.Private)
+
+	view, err = c.ReadCryptoMotionCoinView(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error for a private only asset")
+	assert.Nil(t, view.Public, "should not return a public record for a private only asset")
+	assert.False(t, view.Verified, "should not be verified without a public record")
+	assert.Equal(t, newTestCryptoMotionCoin(), view.Private)
+}
+
+func TestPublicCryptoMotionCoinDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer1 := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	issuer2 := newScenarioIdentity(t, "Org2MSP", "issuer2", roleIssuer)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	transient[publicTransientKey] = []byte("true")
+
+	invokeScenario(t, ledger, chaincode, issuer1, transient, "CreateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, issuer2, transient, "CreateCryptoMotionCoin", "coin1")
+
+	public := new(CryptoMotionCoinPublic)
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer2, nil, "ReadPublicCryptoMotionCoin", "_implicit_org_Org1MSP", "coin1")), public))
+	assert.Equal(t, "Org1MSP", public.OwnerMSP, "should keep the public record of each organization")
+
+	invokeScenario(t, ledger, chaincode, issuer2, nil, "DeleteCryptoMotionCoin", "coin1")
+
+	response := ledger.Invoke(chaincode, issuer1, nil, "ReadPublicCryptoMotionCoin", "_implicit_org_Org2MSP", "coin1")
+	assert.Equal(t, "[NOT_FOUND] The asset coin1 does not have a public record in collection _implicit_org_Org2MSP", response.Message)
+
+	view := new(CryptoMotionCoinView)
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer1, nil, "ReadCryptoMotionCoinView", "coin1")), view))
+	assert.True(t, view.Authorized)
+	assert.True(t, view.Verified, "should not let another organization delete the public record")
+	assert.Equal(t, "Org1MSP", view.Public.OwnerMSP)
+}
@@ -0,0 +1,127 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/events"
+)
+
+// unsupportedPurgeMessage is part of the error a peer older than Fabric 2.5 returns for the purge message it does not know
+const unsupportedPurgeMessage = "cannot handle message"
+
+// purgePrivateData purges a key from a collection, reporting peers that cannot purge private data with an explicit error
+func purgePrivateData(ctx contractapi.TransactionContextInterface, collectionName string, key string) error {
+	err := ctx.GetStub().PurgePrivateData(collectionName, key)
+	if err != nil && strings.Contains(err.Error(), unsupportedPurgeMessage) {
+		return cmcerrors.LedgerErrorf("The peer does not support purging private data. Fabric 2.5 or later is required. %w", err)
+	} else if err != nil {
+		return cmcerrors.LedgerErrorf("Could not purge private data. %w", err)
+	}
+
+	return nil
+}
+
+// purgeCryptoMotionCoinHistory purges the private history entries of a CryptoMotionCoin held in a collection since they
+// carry copies of its value. The public links only hold hashes and are kept so the trail stays verifiable
+func purgeCryptoMotionCoinHistory(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) error {
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(collectionName, historyObjectType, []string{collectionName, cryptoMotionCoinID})
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		err = purgePrivateData(ctx, collectionName, queryResult.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeCryptoMotionCoin erases a CryptoMotionCoin and the private entries of its history from the collection of the client.
+// Unlike DelPrivateData, purging also removes the historical versions the peers keep, so personal data in it can no longer
+// be recovered. A CryptoMotionCoin that was already deleted can still be purged to erase its history. The purge itself is
+// recorded in the public history. Purging requires peers running Fabric 2.5 or later
+func (c *CryptoMotionCoinContract) PurgeCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) error {
+	err := authorize(ctx, "PurgeCryptoMotionCoin")
+	if err != nil {
+		return err
+	}
+
+	exists, err := c.CryptoMotionCoinExists(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return err
+	}
+
+	head, err := getHistoryHead(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	} else if !exists && head == nil {
+		return cmcerrors.NotFoundf("The asset %s does not exist", cryptoMotionCoinID)
+	}
+
+	if exists {
+		existing, err := c.ReadCryptoMotionCoin(ctx, cryptoMotionCoinID)
+		if err != nil {
+			return err
+		}
+
+		err = assertNotHeld(ctx, collectionName, cryptoMotionCoinID)
+		if err != nil {
+			return err
+		}
+
+		indexKeys, err := getCryptoMotionCoinIndexKeys(ctx, cryptoMotionCoinID, existing)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range append([]string{cryptoMotionCoinID}, indexKeys...) {
+			err = purgePrivateData(ctx, collectionName, key)
+			if err != nil {
+				return err
+			}
+		}
+
+		public, err := getPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+		if err != nil {
+			return err
+		} else if public != nil {
+			err = delPublicCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = purgeCryptoMotionCoinHistory(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = recordCryptoMotionCoinHistory(ctx, collectionName, cryptoMotionCoinID, ActionPurge, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return emitCryptoMotionCoinEvent(ctx, events.Purged{Payload: events.NewPayload(cryptoMotionCoinID, collectionName, "")})
+}
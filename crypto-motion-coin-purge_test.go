@@ -0,0 +1,108 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"newprogmodelgoprivatecontract/collections"
+	"newprogmodelgoprivatecontract/events"
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+func TestPurgeCryptoMotionCoin(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	historyKey, _ := getHistoryKey(ctx, "_implicit_org_Org1MSP", "cryptoMotionCoinkey", 0)
+	stub.On("PurgePrivateData", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+	stub.On("GetPrivateDataByPartialCompositeKey", "_implicit_org_Org1MSP", historyObjectType, []string{"_implicit_org_Org1MSP", "cryptoMotionCoinkey"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: historyKey, Value: []byte("{}")},
+	}}, nil)
+
+	err = c.PurgeCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. The admin role is required for PurgeCryptoMotionCoin", "should error when the client is not an admin")
+
+	setClientAttribute(ctx, roleAttribute, roleAdmin)
+
+	err = c.PurgeCryptoMotionCoin(ctx, "missingkey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when the asset has neither a value nor a history")
+
+	err = c.PurgeCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when purging an asset")
+	stub.AssertCalled(t, "PurgePrivateData", "_implicit_org_Org1MSP", "cryptoMotionCoinkey")
+	stub.AssertCalled(t, "PurgePrivateData", "_implicit_org_Org1MSP", indexKey(stub, ownerIndexName, "user1", "cryptoMotionCoinkey"))
+	stub.AssertCalled(t, "PurgePrivateData", "_implicit_org_Org1MSP", indexKey(stub, statusIndexName, "ACTIVE", "cryptoMotionCoinkey"))
+	stub.AssertCalled(t, "PurgePrivateData", "_implicit_org_Org1MSP", historyKey)
+	stub.AssertNotCalled(t, "DelPrivateData", mock.Anything, mock.Anything)
+
+	entryBytes, _ := historyLink(&CryptoMotionCoinHistoryEntry{Sequence: 0, TxID: "txid", Timestamp: txTime, Action: ActionPurge, ClientID: clientAccount})
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", historyKey, entryBytes)
+
+	event, _ := json.Marshal(events.Purged{Payload: events.NewPayload("cryptoMotionCoinkey", "_implicit_org_Org1MSP", "")})
+	stub.AssertCalled(t, "SetEvent", events.PurgedName, event)
+}
+
+func TestPurgeDeletedCryptoMotionCoin(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	setClientAttribute(ctx, roleAttribute, roleAdmin)
+
+	_, head := historyLink(historyEntry(1, ActionDelete, "somehash"))
+	headBytes, _ := canonicalJSON(head)
+	headKey, _ := stub.CreateCompositeKey(historyHeadObjectType, []string{"_implicit_org_Org1MSP", "deletedkey"})
+	historyKey, _ := getHistoryKey(ctx, "_implicit_org_Org1MSP", "deletedkey", 0)
+
+	stub.On("GetPrivateDataHash", mock.AnythingOfType("string"), "deletedkey").Return([]byte(nil), nil)
+	stub.On("GetState", headKey).Return(headBytes, nil)
+	stub.On("PurgePrivateData", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+	stub.On("GetPrivateDataByPartialCompositeKey", "_implicit_org_Org1MSP", historyObjectType, []string{"_implicit_org_Org1MSP", "deletedkey"}).Return(&MockIterator{results: []*queryresult.KV{
+		{Key: historyKey, Value: []byte("{}")},
+	}}, nil)
+
+	err := c.PurgeCryptoMotionCoin(ctx, "deletedkey")
+	assert.Nil(t, err, "should not return error when purging the history of a deleted asset")
+	stub.AssertCalled(t, "PurgePrivateData", "_implicit_org_Org1MSP", historyKey)
+	stub.AssertNotCalled(t, "PurgePrivateData", "_implicit_org_Org1MSP", "deletedkey")
+}
+
+func TestPurgeUnsupported(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	setClientAttribute(ctx, roleAttribute, roleAdmin)
+	stub.On("PurgePrivateData", "_implicit_org_Org1MSP", "cryptoMotionCoinkey").Return(errors.New("[txid] Fabric side handler cannot handle message (PURGE_PRIVATE_DATA) while in ready state"))
+	stub.On("PurgePrivateData", "_implicit_org_Org1MSP", "publickey").Return(errors.New("purge failed"))
+
+	err := c.PurgeCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[LEDGER_ERROR] The peer does not support purging private data. Fabric 2.5 or later is required. [txid] Fabric side handler cannot handle message (PURGE_PRIVATE_DATA) while in ready state", "should explain that the peer is too old to purge")
+
+	err = c.PurgeCryptoMotionCoin(ctx, "publickey")
+	assert.EqualError(t, err, "[LEDGER_ERROR] Could not purge private data. purge failed", "should error when the peer fails to purge")
+}
+
+func TestPurgeDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	admin := newScenarioIdentity(t, "Org1MSP", "admin1", roleAdmin)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	collectionName := collections.ImplicitPrefix + "Org1MSP"
+
+	invokeScenario(t, ledger, chaincode, issuer, transient, "CreateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, admin, nil, "PurgeCryptoMotionCoin", "coin1")
+
+	assert.Nil(t, ledger.PrivateData(collectionName, "coin1"), "should purge the asset through the stub of the peer")
+	assert.Equal(t, "false", invokeScenario(t, ledger, chaincode, issuer, nil, "CryptoMotionCoinExists", "coin1"))
+}
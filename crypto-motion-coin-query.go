@@ -0,0 +1,186 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+const maxPageSize = 1000
+
+// storedCryptoMotionCoinSelector matches the versioned envelopes of stored CryptoMotionCoins, leaving out the agreements,
+// history entries and other documents that share their collection
+var storedCryptoMotionCoinSelector = map[string]interface{}{
+	"schemaVersion": map[string]bool{"$exists": true},
+	"data":          map[string]bool{"$exists": true},
+}
+
+// idSort orders query results by key, the order the bookmarks of pages follow
+var idSort = []map[string]string{{"_id": "asc"}}
+
+// CryptoMotionCoinRecord is a CryptoMotionCoin together with the key it is stored under
+type CryptoMotionCoinRecord struct {
+	ID    string            `json:"id"`
+	Asset *CryptoMotionCoin `json:"asset"`
+}
+
+// CryptoMotionCoinPage is one page of a listing or query. The bookmark is blank on the last page
+type CryptoMotionCoinPage struct {
+	Records             []CryptoMotionCoinRecord `json:"records"`
+	FetchedRecordsCount int32                    `json:"fetchedRecordsCount"`
+	Bookmark            string                   `json:"bookmark"`
+}
+
+func validatePageSize(pageSize int32) error {
+	if pageSize <= 0 || pageSize > maxPageSize {
+		return cmcerrors.InvalidInputf("The page size %d is not valid. It must be between 1 and %d", pageSize, maxPageSize)
+	}
+
+	return nil
+}
+
+// readCryptoMotionCoinPage reads up to pageSize CryptoMotionCoins from the iterator. Private data iterators have no
+// native pagination so the bookmark is the key of the first record of the next page. Iterators start at the bookmark,
+// or at the key following it when that record was deleted since; results before it are skipped
+func readCryptoMotionCoinPage(ctx contractapi.TransactionContextInterface, collectionName string, resultsIterator shim.StateQueryIteratorInterface, pageSize int32, bookmark string) (*CryptoMotionCoinPage, error) {
+	page := new(CryptoMotionCoinPage)
+	page.Records = []CryptoMotionCoinRecord{}
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		if queryResult.Key < bookmark {
+			continue
+		}
+
+		if page.FetchedRecordsCount == pageSize {
+			page.Bookmark = queryResult.Key
+			break
+		}
+
+		cryptoMotionCoin, _, err := decodeCryptoMotionCoin(ctx, collectionName, queryResult.Value)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not unmarshal private data collection data for asset %s. %w", queryResult.Key, err)
+		}
+
+		page.Records = append(page.Records, CryptoMotionCoinRecord{ID: queryResult.Key, Asset: cryptoMotionCoin})
+		page.FetchedRecordsCount++
+	}
+
+	return page, nil
+}
+
+// ListCryptoMotionCoins returns a page of the CryptoMotionCoins whose IDs are in the range [startKey, endKey).
+// Blank keys leave the range open. Pass the bookmark of the previous page to fetch the next one
+func (c *CryptoMotionCoinContract) ListCryptoMotionCoins(ctx contractapi.TransactionContextInterface, startKey string, endKey string, pageSize int32, bookmark string) (*CryptoMotionCoinPage, error) {
+	err := authorize(ctx, "ListCryptoMotionCoins")
+	if err != nil {
+		return nil, err
+	}
+
+	err = validatePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if bookmark != "" {
+		startKey = bookmark
+	}
+
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByRange(collectionName, startKey, endKey)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	}
+	defer resultsIterator.Close()
+
+	return readCryptoMotionCoinPage(ctx, collectionName, resultsIterator, pageSize, bookmark)
+}
+
+// QueryCryptoMotionCoins returns a page of the CryptoMotionCoins matching a CouchDB query such as
+// {"selector":{"data.owner":"user1"}}. Stored CryptoMotionCoins are versioned so their fields are nested under data.
+// Results are sorted by ID so the query cannot set its own sort
+func (c *CryptoMotionCoinContract) QueryCryptoMotionCoins(ctx contractapi.TransactionContextInterface, query string, pageSize int32, bookmark string) (*CryptoMotionCoinPage, error) {
+	err := authorize(ctx, "QueryCryptoMotionCoins")
+	if err != nil {
+		return nil, err
+	}
+
+	err = validatePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed map[string]json.RawMessage
+
+	err = json.Unmarshal([]byte(query), &parsed)
+	if err != nil {
+		return nil, cmcerrors.InvalidInputf("The query is not a valid JSON object. %w", err)
+	} else if _, exists := parsed["selector"]; !exists {
+		return nil, cmcerrors.InvalidInputf("The query must contain a selector")
+	} else if _, exists := parsed["sort"]; exists {
+		return nil, cmcerrors.InvalidInputf("The query must not contain a sort. CryptoMotionCoins are sorted by ID")
+	}
+
+	query, err = buildCryptoMotionCoinQuery(parsed, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetPrivateDataQueryResult(collectionName, query)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	}
+	defer resultsIterator.Close()
+
+	return readCryptoMotionCoinPage(ctx, collectionName, resultsIterator, pageSize, bookmark)
+}
+
+// buildCryptoMotionCoinQuery restricts a query to stored CryptoMotionCoins and sorts them by ID. With a bookmark the query
+// is also restricted to the keys from the bookmark on, so that each page only reads the records from its start
+func buildCryptoMotionCoinQuery(parsed map[string]json.RawMessage, bookmark string) (string, error) {
+	selectors := []interface{}{parsed["selector"], storedCryptoMotionCoinSelector}
+
+	if bookmark != "" {
+		selectors = append(selectors, map[string]interface{}{"_id": map[string]string{"$gte": bookmark}})
+	}
+
+	selector, err := json.Marshal(map[string]interface{}{"$and": selectors})
+	if err != nil {
+		return "", cmcerrors.InvalidInputf("The query selector is not valid. %w", err)
+	}
+
+	sort, err := json.Marshal(idSort)
+	if err != nil {
+		return "", cmcerrors.Internalf("Could not marshal the sort of the query. %w", err)
+	}
+
+	parsed["selector"] = selector
+	parsed["sort"] = sort
+
+	query, err := json.Marshal(parsed)
+	if err != nil {
+		return "", cmcerrors.InvalidInputf("The query is not valid. %w", err)
+	}
+
+	return string(query), nil
+}
@@ -0,0 +1,158 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+const ownerQuery = `{"selector":{"data.owner":"user1"}}`
+
+func queryResults(keys ...string) *MockIterator {
+	stored, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
+
+	results := []*queryresult.KV{}
+
+	for _, key := range keys {
+		results = append(results, &queryresult.KV{Key: key, Value: stored})
+	}
+
+	return &MockIterator{results: results}
+}
+
+func TestListCryptoMotionCoins(t *testing.T) {
+	var page *CryptoMotionCoinPage
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "a", "z").Return(queryResults("a", "b", "c"), nil)
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "c", "z").Return(queryResults("c"), nil)
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "x", "z").Return(queryResults(), nil)
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "bb", "z").Return(queryResults("c"), nil)
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "bad", "").Return(&MockIterator{results: []*queryresult.KV{{Key: "bad", Value: []byte("{")}}}, nil)
+
+	_, err = c.ListCryptoMotionCoins(ctx, "a", "z", 0, "")
+	assert.EqualError(t, err, "[INVALID_INPUT] The page size 0 is not valid. It must be between 1 and 1000", "should error when the page size is not positive")
+
+	_, err = c.ListCryptoMotionCoins(ctx, "a", "z", 1001, "")
+	assert.EqualError(t, err, "[INVALID_INPUT] The page size 1001 is not valid. It must be between 1 and 1000", "should error when the page size is too large")
+
+	page, err = c.ListCryptoMotionCoins(ctx, "a", "z", 2, "")
+	assert.Nil(t, err, "should not return error when listing the first page")
+	assert.Equal(t, []CryptoMotionCoinRecord{{ID: "a", Asset: newTestCryptoMotionCoin()}, {ID: "b", Asset: newTestCryptoMotionCoin()}}, page.Records, "should return the records of the first page")
+	assert.Equal(t, int32(2), page.FetchedRecordsCount)
+	assert.Equal(t, "c", page.Bookmark, "should return the key of the next record as bookmark")
+
+	page, err = c.ListCryptoMotionCoins(ctx, "a", "z", 2, "c")
+	assert.Nil(t, err, "should not return error when listing from a bookmark")
+	assert.Equal(t, &CryptoMotionCoinPage{Records: []CryptoMotionCoinRecord{{ID: "c", Asset: newTestCryptoMotionCoin()}}, FetchedRecordsCount: 1}, page, "should return a blank bookmark on the last page")
+
+	page, err = c.ListCryptoMotionCoins(ctx, "a", "z", 2, "bb")
+	assert.Nil(t, err, "should not return error when the record of the bookmark was deleted")
+	assert.Equal(t, []CryptoMotionCoinRecord{{ID: "c", Asset: newTestCryptoMotionCoin()}}, page.Records, "should resume at the record following the bookmark")
+
+	page, err = c.ListCryptoMotionCoins(ctx, "x", "z", 2, "")
+	assert.Nil(t, err, "should not return error when the range is empty")
+	assert.Equal(t, &CryptoMotionCoinPage{Records: []CryptoMotionCoinRecord{}}, page, "should return an empty page when the range is empty")
+
+	_, err = c.ListCryptoMotionCoins(ctx, "bad", "", 2, "")
+	assert.EqualError(t, err, "[LEDGER_ERROR] Could not unmarshal private data collection data for asset bad. unexpected end of JSON input", "should error when a record cannot be decoded")
+}
+
+func TestQueryCryptoMotionCoins(t *testing.T) {
+	var page *CryptoMotionCoinPage
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stored := `{"data":{"$exists":true},"schemaVersion":{"$exists":true}}`
+	sorted := `"sort":[{"_id":"asc"}]`
+	stub.On("GetPrivateDataQueryResult", "_implicit_org_Org1MSP", `{"selector":{"$and":[{"data.owner":"user1"},`+stored+`]},`+sorted+`}`).Return(queryResults("a", "b", "c"), nil)
+	stub.On("GetPrivateDataQueryResult", "_implicit_org_Org1MSP", `{"selector":{"$and":[{"data.owner":"user1"},`+stored+`,{"_id":{"$gte":"c"}}]},`+sorted+`}`).Return(queryResults("c"), nil)
+	stub.On("GetPrivateDataQueryResult", "_implicit_org_Org1MSP", `{"selector":{"$and":[{"data.owner":"user1"},`+stored+`,{"_id":{"$gte":"bb"}}]},`+sorted+`}`).Return(queryResults("c"), nil)
+
+	_, err = c.QueryCryptoMotionCoins(ctx, "{", 2, "")
+	assert.EqualError(t, err, "[INVALID_INPUT] The query is not a valid JSON object. unexpected end of JSON input", "should error when the query is not JSON")
+
+	_, err = c.QueryCryptoMotionCoins(ctx, `{"limit":2}`, 2, "")
+	assert.EqualError(t, err, "[INVALID_INPUT] The query must contain a selector", "should error when the query has no selector")
+
+	_, err = c.QueryCryptoMotionCoins(ctx, `{"selector":{},"sort":[{"data.amount":"desc"}]}`, 2, "")
+	assert.EqualError(t, err, "[INVALID_INPUT] The query must not contain a sort. CryptoMotionCoins are sorted by ID", "should error when the query sets its own sort")
+
+	page, err = c.QueryCryptoMotionCoins(ctx, ownerQuery, 2, "")
+	assert.Nil(t, err, "should not return error when querying the first page")
+	assert.Equal(t, []CryptoMotionCoinRecord{{ID: "a", Asset: newTestCryptoMotionCoin()}, {ID: "b", Asset: newTestCryptoMotionCoin()}}, page.Records, "should return the matching records of the first page")
+	assert.Equal(t, "c", page.Bookmark, "should return the key of the next record as bookmark")
+
+	page, err = c.QueryCryptoMotionCoins(ctx, ownerQuery, 2, "c")
+	assert.Nil(t, err, "should not return error when querying from a bookmark")
+	assert.Equal(t, &CryptoMotionCoinPage{Records: []CryptoMotionCoinRecord{{ID: "c", Asset: newTestCryptoMotionCoin()}}, FetchedRecordsCount: 1}, page, "should only query the records from the bookmark on")
+
+	page, err = c.QueryCryptoMotionCoins(ctx, ownerQuery, 2, "bb")
+	assert.Nil(t, err, "should not return error when the record of the bookmark was deleted")
+	assert.Equal(t, []CryptoMotionCoinRecord{{ID: "c", Asset: newTestCryptoMotionCoin()}}, page.Records, "should resume at the record following the bookmark")
+}
+
+func TestQueryDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+
+	for _, cryptoMotionCoinID := range []string{"coin1", "coin2", "coin3", "coin4"} {
+		invokeScenario(t, ledger, chaincode, issuer, transient, "CreateCryptoMotionCoin", cryptoMotionCoinID)
+	}
+
+	page := new(CryptoMotionCoinPage)
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "QueryCryptoMotionCoins", ownerQuery, "2", "")), page))
+	assert.Equal(t, "coin3", page.Bookmark)
+
+	invokeScenario(t, ledger, chaincode, issuer, nil, "DeleteCryptoMotionCoin", "coin3")
+
+	page = new(CryptoMotionCoinPage)
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "QueryCryptoMotionCoins", ownerQuery, "2", "coin3")), page))
+	require.Len(t, page.Records, 1, "should resume after a deleted bookmark")
+	assert.Equal(t, "coin4", page.Records[0].ID)
+
+	page = new(CryptoMotionCoinPage)
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "ListCryptoMotionCoins", "", "", "2", "coin3")), page))
+	require.Len(t, page.Records, 1, "should resume after a deleted bookmark")
+	assert.Equal(t, "coin4", page.Records[0].ID)
+}
+
+func TestQueryMixedCollection(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	agreement := newScenarioTransient(t, priceTransientKey, CryptoMotionCoinTransferAgreement{CryptoMotionCoinID: "coin1", Price: 500, TradeID: "trade1", BuyerID: "buyer1"})
+
+	invokeScenario(t, ledger, chaincode, issuer, transient, "CreateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, issuer, transient, "UpdateCryptoMotionCoin", "coin1")
+	invokeScenario(t, ledger, chaincode, issuer, transient, "CreateCryptoMotionCoin", "coin2")
+	invokeScenario(t, ledger, chaincode, issuer, agreement, "AgreeToSellCryptoMotionCoin", "coin1")
+
+	page := new(CryptoMotionCoinPage)
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "QueryCryptoMotionCoins", `{"selector":{}}`, "10", "")), page))
+
+	ids := []string{}
+
+	for _, record := range page.Records {
+		ids = append(ids, record.ID)
+	}
+
+	assert.Equal(t, []string{"coin1", "coin2"}, ids, "should skip the agreements, history entries and indexes sharing the collection")
+}
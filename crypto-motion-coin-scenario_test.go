@@ -0,0 +1,356 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/collections"
+	"newprogmodelgoprivatecontract/events"
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+func newScenarioIdentity(t *testing.T, mspID string, name string, role string) *ledgertest.Identity {
+	identity, err := ledgertest.NewIdentity(mspID, name, map[string]string{roleAttribute: role})
+	require.NoError(t, err)
+
+	return identity
+}
+
+func getScenarioAccountID(t *testing.T, identity *ledgertest.Identity) string {
+	clientIdentity, err := identity.ClientIdentity()
+	require.NoError(t, err)
+
+	id, err := clientIdentity.GetID()
+	require.NoError(t, err)
+
+	return identity.MSPID + ":" + id
+}
+
+func newScenarioTransient(t *testing.T, key string, value interface{}) map[string][]byte {
+	bytes, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	return map[string][]byte{key: bytes}
+}
+
+// newScenarioChaincode returns the chaincode of the contract as the peers run it, so transactions go through the
+// contractapi dispatch layer and the validation of their parameters and results
+func newScenarioChaincode(t *testing.T) *contractapi.ContractChaincode {
+	chaincode, err := contractapi.NewChaincode(new(CryptoMotionCoinContract))
+	require.NoError(t, err)
+
+	return chaincode
+}
+
+// invokeScenario dispatches a transaction to the chaincode and returns its payload, failing the test when it errors
+func invokeScenario(t *testing.T, ledger *ledgertest.Ledger, chaincode *contractapi.ContractChaincode, identity *ledgertest.Identity, transient map[string][]byte, args ...string) string {
+	response := ledger.Invoke(chaincode, identity, transient, args...)
+	require.Equal(t, "", response.Message, "should dispatch %s", args[0])
+
+	return string(response.Payload)
+}
+
+func readScenarioCryptoMotionCoin(ledger *ledgertest.Ledger, identity *ledgertest.Identity, cryptoMotionCoinID string) (*CryptoMotionCoin, error) {
+	var cryptoMotionCoin *CryptoMotionCoin
+
+	err := ledger.Evaluate(identity, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		cryptoMotionCoin, err = new(CryptoMotionCoinContract).ReadCryptoMotionCoin(ctx, cryptoMotionCoinID)
+		return err
+	})
+
+	return cryptoMotionCoin, err
+}
+
+func getScenarioEventNames(ledger *ledgertest.Ledger) []string {
+	names := []string{}
+
+	for _, event := range ledger.Events() {
+		names = append(names, event.Name)
+	}
+
+	return names
+}
+
+func TestScenarioLifecycle(t *testing.T) {
+	c := new(CryptoMotionCoinContract)
+	ledger := ledgertest.NewLedger()
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	operator := newScenarioIdentity(t, "Org1MSP", "operator1", roleOperator)
+	auditor := newScenarioIdentity(t, "Org2MSP", "auditor1", roleAuditor)
+
+	create := func(ctx contractapi.TransactionContextInterface) error {
+		return c.CreateCryptoMotionCoin(ctx, "coin1")
+	}
+
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	assert.NoError(t, ledger.Submit(issuer, transient, create), "should create the asset")
+	assert.True(t, errors.Is(ledger.Submit(issuer, transient, create), cmcerrors.AlreadyExists), "should not create the asset twice")
+
+	cryptoMotionCoin, err := readScenarioCryptoMotionCoin(ledger, operator, "coin1")
+	assert.NoError(t, err, "should read the asset in a later transaction")
+	assert.Equal(t, "user1", cryptoMotionCoin.Owner)
+	assert.Equal(t, int64(100), cryptoMotionCoin.Amount)
+	assert.Equal(t, "Org1MSP", cryptoMotionCoin.IssuerMSP, "should record the issuer")
+	assert.Equal(t, ledgertest.StartTime, cryptoMotionCoin.CreatedAt, "should stamp the transaction time")
+
+	_, err = readScenarioCryptoMotionCoin(ledger, auditor, "coin1")
+	assert.True(t, errors.Is(err, cmcerrors.NotFound), "should keep the asset in the collection of its organization")
+
+	update := func(ctx contractapi.TransactionContextInterface) error {
+		return c.UpdateCryptoMotionCoin(ctx, "coin1")
+	}
+
+	transient = newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user2", Amount: 150, Denomination: "CMC"})
+	assert.True(t, errors.Is(ledger.Submit(operator, transient, update), cmcerrors.Unauthorized), "should only let the creator update the asset")
+	assert.NoError(t, ledger.Submit(issuer, transient, update), "should let the creator update the asset")
+
+	cryptoMotionCoin, err = readScenarioCryptoMotionCoin(ledger, operator, "coin1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user2", cryptoMotionCoin.Owner, "should read the updated asset")
+	assert.Equal(t, int64(150), cryptoMotionCoin.Amount)
+
+	var owned []CryptoMotionCoinRecord
+
+	err = ledger.Evaluate(operator, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		owned, err = c.QueryCryptoMotionCoinsByOwner(ctx, "user2")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []CryptoMotionCoinRecord{{ID: "coin1", Asset: cryptoMotionCoin}}, owned, "should move the owner index with the asset")
+
+	var page *CryptoMotionCoinPage
+
+	err = ledger.Evaluate(operator, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		page, err = c.QueryCryptoMotionCoins(ctx, `{"selector":{"data.owner":"user2"}}`, 10, "")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), page.FetchedRecordsCount, "should find the asset with a rich query")
+
+	assert.NoError(t, ledger.Submit(issuer, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return c.DeleteCryptoMotionCoin(ctx, "coin1")
+	}), "should delete the asset")
+
+	_, err = readScenarioCryptoMotionCoin(ledger, operator, "coin1")
+	assert.True(t, errors.Is(err, cmcerrors.NotFound), "should not read a deleted asset")
+
+	var history []CryptoMotionCoinHistoryRecord
+
+	err = ledger.Evaluate(operator, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		history, err = c.GetCryptoMotionCoinHistory(ctx, "coin1")
+		return err
+	})
+	assert.NoError(t, err, "should keep the history of a deleted asset")
+
+	actions := []CryptoMotionCoinAction{}
+
+	for _, record := range history {
+		actions = append(actions, record.Action)
+		assert.True(t, record.Verified, "should verify the private entries against the public hash chain")
+	}
+
+	assert.Equal(t, []CryptoMotionCoinAction{ActionCreate, ActionUpdate, ActionDelete}, actions, "should record every change")
+	assert.Equal(t, []string{events.CreatedName, events.UpdatedName, events.DeletedName}, getScenarioEventNames(ledger), "should emit an event per committed change")
+}
+
+func TestScenarioTransfer(t *testing.T) {
+	c := new(CryptoMotionCoinContract)
+	ledger := ledgertest.NewLedger()
+	seller := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	colleague := newScenarioIdentity(t, "Org1MSP", "operator1", roleOperator)
+	buyer := newScenarioIdentity(t, "Org2MSP", "operator2", roleOperator)
+	buyerIssuer := newScenarioIdentity(t, "Org2MSP", "issuer2", roleIssuer)
+
+	clientIdentity, err := buyer.ClientIdentity()
+	require.NoError(t, err)
+	buyerID, err := clientIdentity.GetID()
+	require.NoError(t, err)
+
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	transient[publicTransientKey] = []byte("true")
+	require.NoError(t, ledger.Submit(seller, transient, func(ctx contractapi.TransactionContextInterface) error {
+		return c.CreateCryptoMotionCoin(ctx, "coin1")
+	}))
+
+	agree := func(identity *ledgertest.Identity, price int64, transaction func(contractapi.TransactionContextInterface, string) error) error {
+		agreement := CryptoMotionCoinTransferAgreement{CryptoMotionCoinID: "coin1", Price: price, TradeID: "trade1", BuyerID: buyerID}
+
+		return ledger.Submit(identity, newScenarioTransient(t, priceTransientKey, agreement), func(ctx contractapi.TransactionContextInterface) error {
+			return transaction(ctx, "coin1")
+		})
+	}
+
+	transfer := func(ctx contractapi.TransactionContextInterface) error {
+		return c.TransferCryptoMotionCoin(ctx, "coin1", "Org2MSP")
+	}
+
+	assert.True(t, errors.Is(agree(colleague, 500, c.AgreeToSellCryptoMotionCoin), cmcerrors.Unauthorized), "should only let the creator sell the asset")
+	assert.NoError(t, agree(seller, 500, c.AgreeToSellCryptoMotionCoin), "should record the selling price")
+	assert.True(t, errors.Is(ledger.Submit(seller, nil, transfer), cmcerrors.NotFound), "should require an agreement to buy")
+
+	assert.NoError(t, agree(buyer, 400, c.AgreeToBuyCryptoMotionCoin), "should record the buying price")
+	assert.True(t, errors.Is(ledger.Submit(seller, nil, transfer), cmcerrors.InvalidInput), "should compare the hashes of the agreements")
+
+	assert.NoError(t, agree(buyer, 500, c.AgreeToBuyCryptoMotionCoin), "should replace the buying price")
+	assert.True(t, errors.Is(ledger.Submit(colleague, nil, transfer), cmcerrors.Unauthorized), "should only let the creator transfer the asset")
+
+	buyerTransient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user2", Amount: 7, Denomination: "CMC"})
+	require.NoError(t, ledger.Submit(buyerIssuer, buyerTransient, func(ctx contractapi.TransactionContextInterface) error {
+		return c.CreateCryptoMotionCoin(ctx, "coin1")
+	}))
+	assert.True(t, errors.Is(ledger.Submit(seller, nil, transfer), cmcerrors.AlreadyExists), "should not overwrite an asset of the same ID held by the buyer")
+
+	require.NoError(t, ledger.Submit(buyerIssuer, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return c.DeleteCryptoMotionCoin(ctx, "coin1")
+	}))
+	assert.NoError(t, ledger.Submit(seller, nil, transfer), "should transfer once both prices match")
+
+	cryptoMotionCoin, err := readScenarioCryptoMotionCoin(ledger, buyer, "coin1")
+	assert.NoError(t, err, "should move the asset to the collection of the buyer")
+	assert.Equal(t, int64(100), cryptoMotionCoin.Amount)
+	assert.Equal(t, "Org2MSP", cryptoMotionCoin.IssuerMSP, "should make the buyer the issuer")
+	assert.Equal(t, buyerID, cryptoMotionCoin.CreatorID, "should make the buyer the creator")
+
+	var view *CryptoMotionCoinView
+
+	err = ledger.Evaluate(buyer, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		view, err = c.ReadCryptoMotionCoinView(ctx, "coin1")
+		return err
+	})
+	assert.NoError(t, err, "should read the view of the transferred asset")
+	assert.Equal(t, "Org2MSP", view.Public.OwnerMSP, "should move the public record to the buyer")
+	assert.True(t, view.Verified, "should verify the private fields of the buyer against the public record")
+
+	transient = newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user9", Amount: 100, Denomination: "CMC"})
+	assert.NoError(t, ledger.Submit(buyer, transient, func(ctx contractapi.TransactionContextInterface) error {
+		return c.UpdateCryptoMotionCoin(ctx, "coin1")
+	}), "should let the buyer update the asset")
+
+	_, err = readScenarioCryptoMotionCoin(ledger, seller, "coin1")
+	assert.True(t, errors.Is(err, cmcerrors.NotFound), "should remove the asset from the collection of the seller")
+
+	agreementKey := "\x00" + transferAgreementObjectType + "\x00coin1\x00"
+	assert.Nil(t, ledger.PrivateData(collections.ImplicitPrefix+"Org1MSP", agreementKey), "should delete the agreement of the seller")
+	assert.Nil(t, ledger.PrivateData(collections.ImplicitPrefix+"Org2MSP", agreementKey), "should delete the agreement of the buyer")
+
+	var sellerHistory, buyerHistory []CryptoMotionCoinHistoryRecord
+
+	err = ledger.Evaluate(seller, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		sellerHistory, err = c.GetCryptoMotionCoinHistory(ctx, "coin1")
+		return err
+	})
+	require.NoError(t, err, "should keep the history of the seller")
+	require.Len(t, sellerHistory, 2)
+	assert.Equal(t, ActionTransfer, sellerHistory[1].Action, "should close the history of the seller with the transfer")
+
+	err = ledger.Evaluate(buyer, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		buyerHistory, err = c.GetCryptoMotionCoinHistory(ctx, "coin1")
+		return err
+	})
+	require.NoError(t, err, "should keep the history of the buyer")
+
+	actions := []CryptoMotionCoinAction{}
+
+	for _, record := range buyerHistory {
+		actions = append(actions, record.Action)
+		assert.True(t, record.Verified, "should verify the private entries of the buyer against the public hash chain")
+	}
+
+	assert.Equal(t, []CryptoMotionCoinAction{ActionCreate, ActionDelete, ActionTransfer, ActionUpdate}, actions, "should continue the history the buyer already kept for the ID")
+}
+
+func TestScenarioTransferHistory(t *testing.T) {
+	c := new(CryptoMotionCoinContract)
+	ledger := ledgertest.NewLedger()
+	seller := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	buyer := newScenarioIdentity(t, "Org2MSP", "operator2", roleOperator)
+
+	clientIdentity, err := buyer.ClientIdentity()
+	require.NoError(t, err)
+	buyerID, err := clientIdentity.GetID()
+	require.NoError(t, err)
+
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	require.NoError(t, ledger.Submit(seller, transient, func(ctx contractapi.TransactionContextInterface) error {
+		return c.CreateCryptoMotionCoin(ctx, "coin1")
+	}))
+
+	agreement := newScenarioTransient(t, priceTransientKey, CryptoMotionCoinTransferAgreement{CryptoMotionCoinID: "coin1", Price: 500, TradeID: "trade1", BuyerID: buyerID})
+	require.NoError(t, ledger.Submit(seller, agreement, func(ctx contractapi.TransactionContextInterface) error {
+		return c.AgreeToSellCryptoMotionCoin(ctx, "coin1")
+	}))
+	require.NoError(t, ledger.Submit(buyer, agreement, func(ctx contractapi.TransactionContextInterface) error {
+		return c.AgreeToBuyCryptoMotionCoin(ctx, "coin1")
+	}))
+	require.NoError(t, ledger.Submit(seller, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return c.TransferCryptoMotionCoin(ctx, "coin1", "Org2MSP")
+	}))
+
+	histories := map[string][]CryptoMotionCoinHistoryRecord{}
+
+	for name, identity := range map[string]*ledgertest.Identity{"seller": seller, "buyer": buyer} {
+		var history []CryptoMotionCoinHistoryRecord
+
+		err = ledger.Evaluate(identity, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+			history, err = c.GetCryptoMotionCoinHistory(ctx, "coin1")
+			return err
+		})
+		require.NoError(t, err, "should verify the history of the %s", name)
+		histories[name] = history
+	}
+
+	require.Len(t, histories["seller"], 2)
+	assert.Equal(t, []CryptoMotionCoinAction{ActionCreate, ActionTransfer}, []CryptoMotionCoinAction{histories["seller"][0].Action, histories["seller"][1].Action}, "should close the history of the seller with the transfer")
+	require.Len(t, histories["buyer"], 1)
+	assert.Equal(t, ActionTransfer, histories["buyer"][0].Action)
+	assert.Equal(t, histories["seller"][1].Hash, histories["buyer"][0].PreviousHash, "should link the history of the buyer to the one of the seller")
+	assert.True(t, histories["buyer"][0].Verified)
+}
+
+func TestScenarioHoldExpiry(t *testing.T) {
+	c := new(CryptoMotionCoinContract)
+	ledger := ledgertest.NewLedger()
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	notary := newScenarioIdentity(t, "Org1MSP", "notary1", roleOperator)
+
+	transient := newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 100, Denomination: "CMC"})
+	require.NoError(t, ledger.Submit(issuer, transient, func(ctx contractapi.TransactionContextInterface) error {
+		return c.CreateCryptoMotionCoin(ctx, "coin1")
+	}))
+
+	err := ledger.Submit(issuer, nil, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := c.HoldCryptoMotionCoin(ctx, "hold1", "coin1", 0, "Org1MSP:x509::CN=payee", getScenarioAccountID(t, notary), "1h")
+		return err
+	})
+	assert.NoError(t, err, "should hold the asset")
+
+	update := func(ctx contractapi.TransactionContextInterface) error {
+		return c.UpdateCryptoMotionCoin(ctx, "coin1")
+	}
+
+	transient = newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user2", Amount: 100, Denomination: "CMC"})
+	assert.True(t, errors.Is(ledger.Submit(issuer, transient, update), cmcerrors.InvalidInput), "should not update a held asset")
+
+	ledger.Advance(time.Hour)
+	assert.NoError(t, ledger.Submit(issuer, transient, update), "should update the asset once the hold is past its deadline")
+
+	var hold *CryptoMotionCoinHold
+
+	err = ledger.Evaluate(notary, nil, func(ctx contractapi.TransactionContextInterface) (err error) {
+		hold, err = c.ReadHold(ctx, "hold1")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusExpired, hold.Status, "should expire the hold when the asset is updated after the deadline")
+}
@@ -0,0 +1,284 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/collections"
+)
+
+// cryptoMotionCoinSchemaVersion is the version of the CryptoMotionCoin schema written by this chaincode.
+// Version 1 is the original document holding a single privateValue, version 2 is the current CryptoMotionCoin
+const cryptoMotionCoinSchemaVersion = 2
+
+// cryptoMotionCoinEnvelope wraps every CryptoMotionCoin stored in a private data collection
+type cryptoMotionCoinEnvelope struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// upcastContext holds what upcasters may use to fill the fields older schema versions did not record: the organization
+// holding the collection of the CryptoMotionCoin and the time of the transaction reading it
+type upcastContext struct {
+	IssuerMSP string
+	Time      time.Time
+}
+
+// newUpcastContext returns the upcast context of the CryptoMotionCoins of a collection. The issuer of a CryptoMotionCoin in
+// an implicit collection is the organization of the collection, and in a shared collection the organization of the client
+func newUpcastContext(ctx contractapi.TransactionContextInterface, collectionName string) (*upcastContext, error) {
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(collectionName, collections.ImplicitPrefix) {
+		return &upcastContext{IssuerMSP: strings.TrimPrefix(collectionName, collections.ImplicitPrefix), Time: now}, nil
+	}
+
+	mspid, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+	}
+
+	return &upcastContext{IssuerMSP: mspid, Time: now}, nil
+}
+
+// upcaster transforms the data of a stored CryptoMotionCoin from one schema version to the next
+type upcaster func(data map[string]interface{}, context *upcastContext) (map[string]interface{}, error)
+
+// upcasters maps each schema version to the function that converts it to the following version
+var upcasters = map[int]upcaster{
+	1: upcastV1ToV2,
+}
+
+// upcastV1ToV2 keeps the original private value as metadata since version 1 did not record any other field. A version 1
+// asset stood for a single coin held by the organization of its collection, and is dated by the transaction upcasting it
+func upcastV1ToV2(data map[string]interface{}, context *upcastContext) (map[string]interface{}, error) {
+	privateValue, ok := data["privateValue"].(string)
+	if !ok {
+		return nil, cmcerrors.LedgerErrorf("The privateValue of a version 1 asset must be a string")
+	}
+
+	timestamp := context.Time.UTC().Format(time.RFC3339Nano)
+
+	return map[string]interface{}{
+		"owner":        context.IssuerMSP,
+		"amount":       1,
+		"denomination": "CMC",
+		"issuerMSP":    context.IssuerMSP,
+		"status":       string(StatusActive),
+		"metadata":     map[string]interface{}{"privateValue": privateValue},
+		"createdAt":    timestamp,
+		"updatedAt":    timestamp,
+	}, nil
+}
+
+// encodeCryptoMotionCoin serializes a CryptoMotionCoin in its canonical, versioned stored form
+func encodeCryptoMotionCoin(cryptoMotionCoin *CryptoMotionCoin) ([]byte, error) {
+	data, err := canonicalJSON(cryptoMotionCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	return canonicalJSON(cryptoMotionCoinEnvelope{SchemaVersion: cryptoMotionCoinSchemaVersion, Data: data})
+}
+
+// encodeCryptoMotionCoinDocument serializes a client supplied JSON document in its canonical, versioned stored form.
+// Documents without a schemaVersion are taken to be CryptoMotionCoin data of the current version
+func encodeCryptoMotionCoinDocument(document []byte) ([]byte, error) {
+	canonical, err := canonicalizeJSON(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+
+	if json.Unmarshal(canonical, &fields) == nil {
+		if _, versioned := fields["schemaVersion"]; versioned {
+			return canonical, nil
+		}
+	}
+
+	return canonicalJSON(cryptoMotionCoinEnvelope{SchemaVersion: cryptoMotionCoinSchemaVersion, Data: canonical})
+}
+
+// decodeCryptoMotionCoin parses a stored CryptoMotionCoin of any schema version from a collection, upcasting it to the
+// current version. It also reports whether the CryptoMotionCoin was already stored in the current versioned form
+func decodeCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, stored []byte) (*CryptoMotionCoin, bool, error) {
+	var document map[string]interface{}
+
+	err := json.Unmarshal(stored, &document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	version, enveloped, data, err := unwrapCryptoMotionCoin(stored, document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var context *upcastContext
+
+	if version < cryptoMotionCoinSchemaVersion {
+		context, err = newUpcastContext(ctx, collectionName)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for v := version; v < cryptoMotionCoinSchemaVersion; v++ {
+		upcast, exists := upcasters[v]
+		if !exists {
+			return nil, false, cmcerrors.LedgerErrorf("No upcaster from schema version %d", v)
+		}
+
+		data, err = upcast(data, context)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cryptoMotionCoin := new(CryptoMotionCoin)
+
+	err = json.Unmarshal(dataBytes, cryptoMotionCoin)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return cryptoMotionCoin, enveloped && version == cryptoMotionCoinSchemaVersion, nil
+}
+
+// unwrapCryptoMotionCoin returns the schema version and data of a stored document, and whether it was wrapped in an envelope.
+// Documents written before the envelope was introduced are version 1 when they hold a privateValue and version 2 otherwise
+func unwrapCryptoMotionCoin(stored []byte, document map[string]interface{}) (int, bool, map[string]interface{}, error) {
+	if _, versioned := document["schemaVersion"]; !versioned {
+		if _, legacy := document["privateValue"]; legacy {
+			return 1, false, document, nil
+		}
+
+		return 2, false, document, nil
+	}
+
+	envelope := new(cryptoMotionCoinEnvelope)
+
+	err := json.Unmarshal(stored, envelope)
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	if envelope.SchemaVersion < 1 || envelope.SchemaVersion > cryptoMotionCoinSchemaVersion {
+		return 0, false, nil, cmcerrors.LedgerErrorf("The schema version %d is not supported", envelope.SchemaVersion)
+	}
+
+	var data map[string]interface{}
+
+	err = json.Unmarshal(envelope.Data, &data)
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	return envelope.SchemaVersion, true, data, nil
+}
+
+// MigrationResult reports the outcome of migrating one page of CryptoMotionCoins
+type MigrationResult struct {
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+	Bookmark string `json:"bookmark"`
+}
+
+// MigrateCryptoMotionCoins rewrites up to pageSize CryptoMotionCoins, starting at the bookmark, to the current schema version
+// and indexes the rewritten ones. The returned bookmark is blank once the last page has been migrated
+func (c *CryptoMotionCoinContract) MigrateCryptoMotionCoins(ctx contractapi.TransactionContextInterface, bookmark string, pageSize int32) (*MigrationResult, error) {
+	err := authorize(ctx, "MigrateCryptoMotionCoins")
+	if err != nil {
+		return nil, err
+	}
+
+	if pageSize <= 0 {
+		return nil, cmcerrors.InvalidInputf("The page size %d is not valid. It must be a positive integer", pageSize)
+	}
+
+	collectionName, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByRange(collectionName, bookmark, "")
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	}
+	defer resultsIterator.Close()
+
+	result := new(MigrationResult)
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		if result.Scanned == int(pageSize) {
+			result.Bookmark = queryResult.Key
+			break
+		}
+
+		result.Scanned++
+
+		cryptoMotionCoin, current, err := decodeCryptoMotionCoin(ctx, collectionName, queryResult.Value)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not unmarshal private data collection data for asset %s. %w", queryResult.Key, err)
+		} else if current {
+			continue
+		}
+
+		err = cryptoMotionCoin.Validate()
+		if err != nil {
+			return nil, cmcerrors.InvalidInputf("Could not migrate asset %s. %w", queryResult.Key, errors.Unwrap(err))
+		}
+
+		migrated, err := encodeCryptoMotionCoin(cryptoMotionCoin)
+		if err != nil {
+			return nil, err
+		}
+
+		err = ctx.GetStub().PutPrivateData(collectionName, queryResult.Key, migrated)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+		}
+
+		err = putCryptoMotionCoinIndexes(ctx, collectionName, queryResult.Key, cryptoMotionCoin)
+		if err != nil {
+			return nil, err
+		}
+
+		err = recordCryptoMotionCoinHistory(ctx, collectionName, queryResult.Key, ActionMigrate, migrated, cryptoMotionCoin)
+		if err != nil {
+			return nil, err
+		}
+
+		err = refreshPublicCryptoMotionCoin(ctx, collectionName, queryResult.Key, cryptoMotionCoin, migrated)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Migrated++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,173 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+const legacyJSON = `{"privateValue":"set value"}`
+
+func newLegacyCryptoMotionCoin(mspid string) *CryptoMotionCoin {
+	return &CryptoMotionCoin{
+		Owner:        mspid,
+		Amount:       1,
+		Denomination: "CMC",
+		IssuerMSP:    mspid,
+		Status:       StatusActive,
+		Metadata:     map[string]string{"privateValue": "set value"},
+		CreatedAt:    txTime,
+		UpdatedAt:    txTime,
+	}
+}
+
+func TestDecodeCryptoMotionCoin(t *testing.T) {
+	var cryptoMotionCoin *CryptoMotionCoin
+	var current bool
+	var err error
+
+	ctx, _ := configureStub()
+
+	stored, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
+	cryptoMotionCoin, current, err = decodeCryptoMotionCoin(ctx, "_implicit_org_Org1MSP", stored)
+	assert.Nil(t, err, "should not return error for the current schema version")
+	assert.True(t, current, "should report the current schema version as current")
+	assert.Equal(t, newTestCryptoMotionCoin(), cryptoMotionCoin)
+
+	unversioned, _ := canonicalJSON(newTestCryptoMotionCoin())
+	cryptoMotionCoin, current, err = decodeCryptoMotionCoin(ctx, "_implicit_org_Org1MSP", unversioned)
+	assert.Nil(t, err, "should not return error for a version 2 document without envelope")
+	assert.False(t, current, "should not report a document without envelope as current")
+	assert.Equal(t, newTestCryptoMotionCoin(), cryptoMotionCoin)
+
+	cryptoMotionCoin, current, err = decodeCryptoMotionCoin(ctx, "_implicit_org_Org2MSP", []byte(legacyJSON))
+	assert.Nil(t, err, "should not return error for a version 1 document")
+	assert.False(t, current, "should not report a version 1 document as current")
+	assert.Equal(t, newLegacyCryptoMotionCoin("Org2MSP"), cryptoMotionCoin, "should upcast a version 1 document to a coin of the organization of its collection")
+	assert.Nil(t, cryptoMotionCoin.Validate(), "should upcast a version 1 document to a valid asset")
+
+	cryptoMotionCoin, _, err = decodeCryptoMotionCoin(ctx, "CryptoMotionCoinShared", []byte(legacyJSON))
+	assert.Nil(t, err, "should not return error for a version 1 document of a shared collection")
+	assert.Equal(t, newLegacyCryptoMotionCoin("Org1MSP"), cryptoMotionCoin, "should upcast a version 1 document of a shared collection to a coin of the client organization")
+
+	_, _, err = decodeCryptoMotionCoin(ctx, "_implicit_org_Org1MSP", []byte(`{"data":{},"schemaVersion":3}`))
+	assert.EqualError(t, err, "[LEDGER_ERROR] The schema version 3 is not supported", "should error for an unknown schema version")
+
+	_, _, err = decodeCryptoMotionCoin(ctx, "_implicit_org_Org1MSP", []byte(`{"privateValue":1}`))
+	assert.EqualError(t, err, "[LEDGER_ERROR] The privateValue of a version 1 asset must be a string", "should error when an upcaster fails")
+}
+
+func TestEncodeCryptoMotionCoinDocument(t *testing.T) {
+	var encoded []byte
+	var err error
+
+	encoded, err = encodeCryptoMotionCoinDocument([]byte(`{ "owner": "user1" }`))
+	assert.Nil(t, err, "should not return error for a document without envelope")
+	assert.Equal(t, `{"data":{"owner":"user1"},"schemaVersion":2}`, string(encoded), "should wrap a document without envelope in the current version")
+
+	encoded, err = encodeCryptoMotionCoinDocument([]byte(`{ "schemaVersion": 2, "data": { "owner": "user1" } }`))
+	assert.Nil(t, err, "should not return error for a document with envelope")
+	assert.Equal(t, `{"data":{"owner":"user1"},"schemaVersion":2}`, string(encoded), "should keep the envelope of a versioned document")
+}
+
+func TestMigrateCryptoMotionCoins(t *testing.T) {
+	var result *MigrationResult
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	current, _ := encodeCryptoMotionCoin(newTestCryptoMotionCoin())
+	unversioned, _ := canonicalJSON(newTestCryptoMotionCoin())
+
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "", "").Return(&MockIterator{results: []*queryresult.KV{
+		{Key: "a", Value: []byte(legacyJSON)},
+		{Key: "b", Value: current},
+		{Key: "c", Value: unversioned},
+	}}, nil)
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "c", "").Return(&MockIterator{results: []*queryresult.KV{
+		{Key: "c", Value: unversioned},
+	}}, nil)
+	stub.On("GetState", mock.AnythingOfType("string")).Return([]byte(nil), nil)
+
+	result, err = c.MigrateCryptoMotionCoins(ctx, "", 2)
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. The admin role is required for MigrateCryptoMotionCoins", "should error when the client is not an admin")
+	assert.Nil(t, result)
+
+	setClientAttribute(ctx, roleAttribute, roleAdmin)
+
+	result, err = c.MigrateCryptoMotionCoins(ctx, "", 0)
+	assert.EqualError(t, err, "[INVALID_INPUT] The page size 0 is not valid. It must be a positive integer", "should error when the page size is not positive")
+
+	result, err = c.MigrateCryptoMotionCoins(ctx, "", 2)
+	assert.Nil(t, err, "should not return error when migrating a page")
+	assert.Equal(t, &MigrationResult{Scanned: 2, Migrated: 1, Bookmark: "c"}, result, "should migrate outdated records of the page and return the next bookmark")
+	legacyMigrated, _ := encodeCryptoMotionCoin(newLegacyCryptoMotionCoin("Org1MSP"))
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "a", legacyMigrated)
+	stub.AssertNotCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "b", mock.Anything)
+
+	result, err = c.MigrateCryptoMotionCoins(ctx, "c", 2)
+	assert.Nil(t, err, "should not return error when migrating the last page")
+	assert.Equal(t, &MigrationResult{Scanned: 1, Migrated: 1, Bookmark: ""}, result, "should return a blank bookmark after the last page")
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "c", current)
+}
+
+func TestReadLegacyCryptoMotionCoin(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	stub.On("GetPrivateDataHash", mock.AnythingOfType("string"), "legacykey").Return(hashOf(legacyJSON), nil)
+	stub.On("GetPrivateData", mock.AnythingOfType("string"), "legacykey").Return([]byte(legacyJSON), nil)
+
+	cryptoMotionCoin, err := c.ReadCryptoMotionCoin(ctx, "legacykey")
+	assert.Nil(t, err, "should not return error when reading a version 1 record")
+	assert.Equal(t, map[string]string{"privateValue": "set value"}, cryptoMotionCoin.Metadata, "should upcast a version 1 record on read")
+}
+
+func TestMigrateInvalidCryptoMotionCoin(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(CryptoMotionCoinContract)
+
+	setClientAttribute(ctx, roleAttribute, roleAdmin)
+
+	stub.On("GetPrivateDataByRange", "_implicit_org_Org1MSP", "", "").Return(&MockIterator{results: []*queryresult.KV{
+		{Key: "a", Value: []byte(`{"amount":5,"denomination":"CMC","issuerMSP":"Org1MSP","status":"ACTIVE","createdAt":"2020-06-01T12:00:00Z","updatedAt":"2020-06-01T12:00:00Z"}`)},
+	}}, nil)
+
+	result, err := c.MigrateCryptoMotionCoins(ctx, "", 2)
+	assert.EqualError(t, err, "[INVALID_INPUT] Could not migrate asset a. The asset is not valid. owner must be specified", "should not write records that would not validate")
+	assert.Nil(t, result)
+	stub.AssertNotCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", "a", mock.Anything)
+}
+
+func TestMigrationDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	admin := newScenarioIdentity(t, "Org1MSP", "admin1", roleAdmin)
+
+	err := ledger.Submit(admin, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutPrivateData("_implicit_org_Org1MSP", "legacy1", []byte(legacyJSON))
+	})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"scanned":1,"migrated":1,"bookmark":""}`, invokeScenario(t, ledger, chaincode, admin, nil, "MigrateCryptoMotionCoins", "", "10"))
+
+	migrated, current, err := decodeCryptoMotionCoin(nil, "_implicit_org_Org1MSP", ledger.PrivateData("_implicit_org_Org1MSP", "legacy1"))
+	require.NoError(t, err)
+	assert.True(t, current, "should store the current schema version")
+	assert.Nil(t, migrated.Validate(), "should store a valid asset")
+	assert.Equal(t, "Org1MSP", migrated.Owner)
+	assert.Equal(t, int64(1), migrated.Amount)
+
+	invokeScenario(t, ledger, chaincode, admin, newScenarioTransient(t, cryptoMotionCoinTransientKey, CryptoMotionCoin{Owner: "user1", Amount: 5, Denomination: "CMC"}), "UpdateCryptoMotionCoin", "legacy1")
+}
@@ -0,0 +1,341 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+const balanceObjectType = "balance"
+const totalSupplyObjectType = "totalSupply"
+const allowanceObjectType = "allowance"
+
+func getClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspid, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+	}
+
+	id, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+	}
+
+	return mspid + ":" + id, nil
+}
+
+// splitAccountID returns the MSP ID and client ID of an account ID, and whether the account ID has both
+func splitAccountID(account string) (string, string, bool) {
+	i := strings.Index(account, ":")
+	if i <= 0 || i == len(account)-1 {
+		return "", "", false
+	}
+
+	return account[:i], account[i+1:], true
+}
+
+func validateAmount(amount int64) error {
+	if amount <= 0 {
+		return cmcerrors.InvalidInputf("The amount %d is not valid. It must be a positive integer", amount)
+	}
+
+	return nil
+}
+
+func addAmounts(a int64, b int64) (int64, error) {
+	if b > 0 && a > math.MaxInt64-b {
+		return 0, cmcerrors.InvalidInputf("Adding %d to %d would overflow", b, a)
+	}
+
+	return a + b, nil
+}
+
+func subtractAmounts(a int64, b int64) (int64, error) {
+	if b > a {
+		return 0, cmcerrors.InvalidInputf("Insufficient funds. Cannot subtract %d from %d", b, a)
+	}
+
+	return a - b, nil
+}
+
+func readAmount(ctx contractapi.TransactionContextInterface, key string) (int64, error) {
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return 0, nil
+	}
+
+	amount, err := strconv.ParseInt(string(bytes), 10, 64)
+	if err != nil {
+		return 0, cmcerrors.LedgerErrorf("Could not parse amount stored under key %s", key)
+	}
+
+	return amount, nil
+}
+
+func writeAmount(ctx contractapi.TransactionContextInterface, key string, amount int64) error {
+	err := ctx.GetStub().PutState(key, []byte(strconv.FormatInt(amount, 10)))
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return nil
+}
+
+func getBalanceKey(ctx contractapi.TransactionContextInterface, account string) (string, error) {
+	return createCompositeKey(ctx, balanceObjectType, []string{account})
+}
+
+func getTotalSupplyKey(ctx contractapi.TransactionContextInterface) (string, error) {
+	return createCompositeKey(ctx, totalSupplyObjectType, []string{})
+}
+
+func getAllowanceKey(ctx contractapi.TransactionContextInterface, owner string, spender string) (string, error) {
+	return createCompositeKey(ctx, allowanceObjectType, []string{owner, spender})
+}
+
+func adjustBalance(ctx contractapi.TransactionContextInterface, account string, delta int64) error {
+	balanceKey, err := getBalanceKey(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	balance, err := readAmount(ctx, balanceKey)
+	if err != nil {
+		return err
+	}
+
+	if delta >= 0 {
+		balance, err = addAmounts(balance, delta)
+	} else {
+		balance, err = subtractAmounts(balance, -delta)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writeAmount(ctx, balanceKey, balance)
+}
+
+func adjustTotalSupply(ctx contractapi.TransactionContextInterface, delta int64) error {
+	totalSupplyKey, err := getTotalSupplyKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	totalSupply, err := readAmount(ctx, totalSupplyKey)
+	if err != nil {
+		return err
+	}
+
+	if delta >= 0 {
+		totalSupply, err = addAmounts(totalSupply, delta)
+	} else {
+		totalSupply, err = subtractAmounts(totalSupply, -delta)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writeAmount(ctx, totalSupplyKey, totalSupply)
+}
+
+func transferBalance(ctx contractapi.TransactionContextInterface, from string, to string, amount int64) error {
+	if to == "" {
+		return cmcerrors.InvalidInputf("The recipient account must be specified")
+	} else if from == to {
+		return cmcerrors.InvalidInputf("Cannot transfer to the sending account %s", from)
+	}
+
+	err := adjustBalance(ctx, from, -amount)
+	if err != nil {
+		return err
+	}
+
+	return adjustBalance(ctx, to, amount)
+}
+
+// ClientAccountID returns the account identifier of the submitting client
+func (c *CryptoMotionCoinContract) ClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
+	return getClientAccountID(ctx)
+}
+
+// Mint creates new coins and credits them to the account of the submitting client
+func (c *CryptoMotionCoinContract) Mint(ctx contractapi.TransactionContextInterface, amount int64) error {
+	err := authorize(ctx, "Mint")
+	if err != nil {
+		return err
+	}
+
+	err = validateAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	account, err := getClientAccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = adjustTotalSupply(ctx, amount)
+	if err != nil {
+		return err
+	}
+
+	return adjustBalance(ctx, account, amount)
+}
+
+// Burn destroys coins held by the account of the submitting client
+func (c *CryptoMotionCoinContract) Burn(ctx contractapi.TransactionContextInterface, amount int64) error {
+	err := authorize(ctx, "Burn")
+	if err != nil {
+		return err
+	}
+
+	err = validateAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	account, err := getClientAccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = adjustBalance(ctx, account, -amount)
+	if err != nil {
+		return err
+	}
+
+	return adjustTotalSupply(ctx, -amount)
+}
+
+// Transfer moves coins from the account of the submitting client to the recipient account
+func (c *CryptoMotionCoinContract) Transfer(ctx contractapi.TransactionContextInterface, recipient string, amount int64) error {
+	err := authorize(ctx, "Transfer")
+	if err != nil {
+		return err
+	}
+
+	err = validateAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	account, err := getClientAccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return transferBalance(ctx, account, recipient, amount)
+}
+
+// BalanceOf returns the number of coins held by the given account
+func (c *CryptoMotionCoinContract) BalanceOf(ctx contractapi.TransactionContextInterface, account string) (int64, error) {
+	balanceKey, err := getBalanceKey(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+
+	return readAmount(ctx, balanceKey)
+}
+
+// TotalSupply returns the number of coins in circulation
+func (c *CryptoMotionCoinContract) TotalSupply(ctx contractapi.TransactionContextInterface) (int64, error) {
+	totalSupplyKey, err := getTotalSupplyKey(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return readAmount(ctx, totalSupplyKey)
+}
+
+// Approve allows the spender account to transfer up to amount coins from the account of the submitting client
+func (c *CryptoMotionCoinContract) Approve(ctx contractapi.TransactionContextInterface, spender string, amount int64) error {
+	err := authorize(ctx, "Approve")
+	if err != nil {
+		return err
+	}
+
+	if amount < 0 {
+		return cmcerrors.InvalidInputf("The amount %d is not valid. It must not be negative", amount)
+	} else if spender == "" {
+		return cmcerrors.InvalidInputf("The spender account must be specified")
+	}
+
+	owner, err := getClientAccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if owner == spender {
+		return cmcerrors.InvalidInputf("Cannot approve the owning account %s as a spender", owner)
+	}
+
+	allowanceKey, err := getAllowanceKey(ctx, owner, spender)
+	if err != nil {
+		return err
+	}
+
+	return writeAmount(ctx, allowanceKey, amount)
+}
+
+// Allowance returns the number of coins the spender account may still transfer from the owner account
+func (c *CryptoMotionCoinContract) Allowance(ctx contractapi.TransactionContextInterface, owner string, spender string) (int64, error) {
+	allowanceKey, err := getAllowanceKey(ctx, owner, spender)
+	if err != nil {
+		return 0, err
+	}
+
+	return readAmount(ctx, allowanceKey)
+}
+
+// TransferFrom moves coins from the owner account to the recipient account on behalf of the submitting client
+func (c *CryptoMotionCoinContract) TransferFrom(ctx contractapi.TransactionContextInterface, owner string, recipient string, amount int64) error {
+	err := authorize(ctx, "TransferFrom")
+	if err != nil {
+		return err
+	}
+
+	err = validateAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	spender, err := getClientAccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	allowanceKey, err := getAllowanceKey(ctx, owner, spender)
+	if err != nil {
+		return err
+	}
+
+	allowance, err := readAmount(ctx, allowanceKey)
+	if err != nil {
+		return err
+	}
+
+	if amount > allowance {
+		return cmcerrors.Unauthorizedf("The spender %s is only allowed to transfer %d from %s", spender, allowance, owner)
+	}
+
+	err = writeAmount(ctx, allowanceKey, allowance-amount)
+	if err != nil {
+		return err
+	}
+
+	return transferBalance(ctx, owner, recipient, amount)
+}
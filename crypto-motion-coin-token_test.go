@@ -0,0 +1,242 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+const clientAccount = "Org1MSP:x509::CN=user1"
+
+func configureTokenStub(balances map[string]int64, totalSupply int64, allowances map[string]int64) (*MockContext, *MockStub) {
+	var nilBytes []byte
+
+	ctx, ms := configureStub()
+
+	for account, balance := range balances {
+		balanceKey, _ := ms.CreateCompositeKey(balanceObjectType, []string{account})
+		ms.On("GetState", balanceKey).Return([]byte(strconv.FormatInt(balance, 10)), nil)
+	}
+
+	totalSupplyKey, _ := ms.CreateCompositeKey(totalSupplyObjectType, []string{})
+	ms.On("GetState", totalSupplyKey).Return([]byte(strconv.FormatInt(totalSupply, 10)), nil)
+
+	for owner, allowance := range allowances {
+		configureAllowance(ms, owner, clientAccount, allowance)
+	}
+
+	badKey, _ := ms.CreateCompositeKey(balanceObjectType, []string{"statebad"})
+	ms.On("GetState", badKey).Return(nilBytes, errors.New(getStateError))
+	ms.On("GetState", mock.AnythingOfType("string")).Return(nilBytes, nil)
+
+	return ctx, ms
+}
+
+func configureAllowance(ms *MockStub, owner string, spender string, allowance int64) string {
+	allowanceKey, _ := ms.CreateCompositeKey(allowanceObjectType, []string{owner, spender})
+	ms.On("GetState", allowanceKey).Return([]byte(strconv.FormatInt(allowance, 10)), nil)
+
+	return allowanceKey
+}
+
+func balanceKey(ms *MockStub, account string) string {
+	key, _ := ms.CreateCompositeKey(balanceObjectType, []string{account})
+	return key
+}
+
+func TestMint(t *testing.T) {
+	var err error
+
+	ctx, stub := configureTokenStub(map[string]int64{clientAccount: 100}, 100, nil)
+	c := new(CryptoMotionCoinContract)
+
+	err = c.Mint(ctx, 0)
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount 0 is not valid. It must be a positive integer", "should error when amount is zero")
+
+	err = c.Mint(ctx, -5)
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount -5 is not valid. It must be a positive integer", "should error when amount is negative")
+
+	err = c.Mint(ctx, 50)
+	assert.Nil(t, err, "should not return error when minting a positive amount")
+	totalSupplyKey, _ := stub.CreateCompositeKey(totalSupplyObjectType, []string{})
+	stub.AssertCalled(t, "PutState", totalSupplyKey, []byte("150"))
+	stub.AssertCalled(t, "PutState", balanceKey(stub, clientAccount), []byte("150"))
+
+	ctx, _ = configureTokenStub(map[string]int64{clientAccount: 1}, math.MaxInt64, nil)
+	err = c.Mint(ctx, 1)
+	assert.EqualError(t, err, fmt.Sprintf("[INVALID_INPUT] Adding 1 to %d would overflow", int64(math.MaxInt64)), "should error when total supply would overflow")
+}
+
+func TestBurn(t *testing.T) {
+	var err error
+
+	ctx, stub := configureTokenStub(map[string]int64{clientAccount: 100}, 100, nil)
+	c := new(CryptoMotionCoinContract)
+
+	err = c.Burn(ctx, 0)
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount 0 is not valid. It must be a positive integer", "should error when amount is zero")
+
+	err = c.Burn(ctx, 101)
+	assert.EqualError(t, err, "[INVALID_INPUT] Insufficient funds. Cannot subtract 101 from 100", "should error when burning more than the balance")
+
+	err = c.Burn(ctx, 40)
+	assert.Nil(t, err, "should not return error when burning part of the balance")
+	totalSupplyKey, _ := stub.CreateCompositeKey(totalSupplyObjectType, []string{})
+	stub.AssertCalled(t, "PutState", totalSupplyKey, []byte("60"))
+	stub.AssertCalled(t, "PutState", balanceKey(stub, clientAccount), []byte("60"))
+}
+
+func TestTransfer(t *testing.T) {
+	var err error
+
+	ctx, stub := configureTokenStub(map[string]int64{clientAccount: 100, "recipient": math.MaxInt64}, 100, nil)
+	c := new(CryptoMotionCoinContract)
+
+	err = c.Transfer(ctx, "other", -1)
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount -1 is not valid. It must be a positive integer", "should error when amount is negative")
+
+	err = c.Transfer(ctx, "", 10)
+	assert.EqualError(t, err, "[INVALID_INPUT] The recipient account must be specified", "should error when recipient is blank")
+
+	err = c.Transfer(ctx, clientAccount, 10)
+	assert.EqualError(t, err, fmt.Sprintf("[INVALID_INPUT] Cannot transfer to the sending account %s", clientAccount), "should error when transferring to self")
+
+	err = c.Transfer(ctx, "other", 200)
+	assert.EqualError(t, err, "[INVALID_INPUT] Insufficient funds. Cannot subtract 200 from 100", "should error when transferring more than the balance")
+
+	err = c.Transfer(ctx, "recipient", 10)
+	assert.EqualError(t, err, fmt.Sprintf("[INVALID_INPUT] Adding 10 to %d would overflow", int64(math.MaxInt64)), "should error when recipient balance would overflow")
+
+	err = c.Transfer(ctx, "other", 30)
+	assert.Nil(t, err, "should not return error when transferring part of the balance")
+	stub.AssertCalled(t, "PutState", balanceKey(stub, clientAccount), []byte("70"))
+	stub.AssertCalled(t, "PutState", balanceKey(stub, "other"), []byte("30"))
+}
+
+func TestBalanceOf(t *testing.T) {
+	var balance int64
+	var err error
+
+	ctx, _ := configureTokenStub(map[string]int64{clientAccount: 100}, 100, nil)
+	c := new(CryptoMotionCoinContract)
+
+	balance, err = c.BalanceOf(ctx, "statebad")
+	assert.EqualError(t, err, fmt.Sprintf("[LEDGER_ERROR] Could not read from world state. %s", getStateError), "should error when balance cannot be read")
+	assert.Equal(t, int64(0), balance)
+
+	balance, err = c.BalanceOf(ctx, "unknown")
+	assert.Nil(t, err, "should not return error for an account without coins")
+	assert.Equal(t, int64(0), balance, "should return zero for an account without coins")
+
+	balance, err = c.BalanceOf(ctx, clientAccount)
+	assert.Nil(t, err, "should not return error for an account with coins")
+	assert.Equal(t, int64(100), balance, "should return the stored balance")
+}
+
+func TestTotalSupply(t *testing.T) {
+	ctx, _ := configureTokenStub(map[string]int64{clientAccount: 100}, 250, nil)
+	c := new(CryptoMotionCoinContract)
+
+	totalSupply, err := c.TotalSupply(ctx)
+	assert.Nil(t, err, "should not return error when reading the total supply")
+	assert.Equal(t, int64(250), totalSupply, "should return the stored total supply")
+
+	account, err := c.ClientAccountID(ctx)
+	assert.Nil(t, err, "should not return error when reading the client account")
+	assert.Equal(t, clientAccount, account, "should derive the account from the MSP ID and client ID")
+}
+
+func TestApprove(t *testing.T) {
+	var err error
+
+	ctx, stub := configureTokenStub(map[string]int64{clientAccount: 100}, 100, nil)
+	c := new(CryptoMotionCoinContract)
+
+	err = c.Approve(ctx, "bot", -1)
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount -1 is not valid. It must not be negative", "should error when allowance is negative")
+
+	err = c.Approve(ctx, "", 10)
+	assert.EqualError(t, err, "[INVALID_INPUT] The spender account must be specified", "should error when spender is blank")
+
+	err = c.Approve(ctx, clientAccount, 10)
+	assert.EqualError(t, err, fmt.Sprintf("[INVALID_INPUT] Cannot approve the owning account %s as a spender", clientAccount), "should error when approving self")
+
+	err = c.Approve(ctx, "bot", 25)
+	assert.Nil(t, err, "should not return error when approving a spender")
+	allowanceKey, _ := stub.CreateCompositeKey(allowanceObjectType, []string{clientAccount, "bot"})
+	stub.AssertCalled(t, "PutState", allowanceKey, []byte("25"))
+}
+
+func TestAllowance(t *testing.T) {
+	ctx, _ := configureTokenStub(map[string]int64{}, 0, map[string]int64{"owner": 40})
+	c := new(CryptoMotionCoinContract)
+
+	allowance, err := c.Allowance(ctx, "owner", clientAccount)
+	assert.Nil(t, err, "should not return error when reading an allowance")
+	assert.Equal(t, int64(40), allowance, "should return the stored allowance")
+
+	allowance, err = c.Allowance(ctx, "owner", "nobody")
+	assert.Nil(t, err, "should not return error when no allowance exists")
+	assert.Equal(t, int64(0), allowance, "should return zero when no allowance exists")
+}
+
+func TestTransferFrom(t *testing.T) {
+	var err error
+
+	ctx, stub := configureTokenStub(map[string]int64{"owner": 100, "poor": 5}, 105, map[string]int64{"owner": 40, "poor": 40})
+	c := new(CryptoMotionCoinContract)
+
+	err = c.TransferFrom(ctx, "owner", "recipient", 0)
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount 0 is not valid. It must be a positive integer", "should error when amount is zero")
+
+	err = c.TransferFrom(ctx, "owner", "recipient", 41)
+	assert.EqualError(t, err, fmt.Sprintf("[UNAUTHORIZED] The spender %s is only allowed to transfer 40 from owner", clientAccount), "should error when exceeding the allowance")
+
+	err = c.TransferFrom(ctx, "stranger", "recipient", 1)
+	assert.EqualError(t, err, fmt.Sprintf("[UNAUTHORIZED] The spender %s is only allowed to transfer 0 from stranger", clientAccount), "should error when no allowance was approved")
+
+	err = c.TransferFrom(ctx, "poor", "recipient", 10)
+	assert.EqualError(t, err, "[INVALID_INPUT] Insufficient funds. Cannot subtract 10 from 5", "should error when owner balance is too low")
+
+	err = c.TransferFrom(ctx, "owner", "recipient", 15)
+	assert.Nil(t, err, "should not return error when transferring within the allowance")
+	allowanceKey, _ := stub.CreateCompositeKey(allowanceObjectType, []string{"owner", clientAccount})
+	stub.AssertCalled(t, "PutState", allowanceKey, []byte("25"))
+	stub.AssertCalled(t, "PutState", balanceKey(stub, "owner"), []byte("85"))
+	stub.AssertCalled(t, "PutState", balanceKey(stub, "recipient"), []byte("15"))
+}
+
+func TestTokenDispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	operator := newScenarioIdentity(t, "Org1MSP", "operator1", roleOperator)
+	issuerAccount := getScenarioAccountID(t, issuer)
+	operatorAccount := getScenarioAccountID(t, operator)
+
+	response := ledger.Invoke(chaincode, operator, nil, "Mint", "1000")
+	assert.Equal(t, "[UNAUTHORIZED] Access denied. The issuer role is required for Mint", response.Message, "should only let issuers mint")
+
+	invokeScenario(t, ledger, chaincode, issuer, nil, "Mint", "100")
+	invokeScenario(t, ledger, chaincode, issuer, nil, "Transfer", operatorAccount, "30")
+	invokeScenario(t, ledger, chaincode, operator, nil, "Approve", issuerAccount, "20")
+	invokeScenario(t, ledger, chaincode, issuer, nil, "TransferFrom", operatorAccount, issuerAccount, "5")
+	invokeScenario(t, ledger, chaincode, operator, nil, "Burn", "10")
+
+	assert.Equal(t, issuerAccount, invokeScenario(t, ledger, chaincode, issuer, nil, "ClientAccountID"))
+	assert.Equal(t, "75", invokeScenario(t, ledger, chaincode, issuer, nil, "BalanceOf", issuerAccount))
+	assert.Equal(t, "15", invokeScenario(t, ledger, chaincode, issuer, nil, "BalanceOf", operatorAccount))
+	assert.Equal(t, "15", invokeScenario(t, ledger, chaincode, issuer, nil, "Allowance", operatorAccount, issuerAccount))
+	assert.Equal(t, "90", invokeScenario(t, ledger, chaincode, issuer, nil, "TotalSupply"))
+}
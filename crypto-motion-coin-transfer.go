@@ -0,0 +1,319 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+	"newprogmodelgoprivatecontract/collections"
+	"newprogmodelgoprivatecontract/events"
+)
+
+const transferAgreementObjectType = "transferAgreement"
+
+// priceTransientKey is the transient data key holding the CryptoMotionCoinTransferAgreement of a seller or buyer
+const priceTransientKey = "price"
+
+// CryptoMotionCoinTransferAgreement stores the price an organization agrees to buy or sell a CryptoMotionCoin for. BuyerID
+// is the client ID of the buyer, who becomes the creator of the CryptoMotionCoin once it is transferred
+type CryptoMotionCoinTransferAgreement struct {
+	CryptoMotionCoinID string `json:"cryptoMotionCoinID"`
+	Price              int64  `json:"price"`
+	TradeID            string `json:"tradeID"`
+	BuyerID            string `json:"buyerID"`
+}
+
+func getImplicitCollectionName(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspid, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+	}
+
+	return collections.ImplicitPrefix + mspid, nil
+}
+
+func getTransferAgreementKey(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) (string, error) {
+	return createCompositeKey(ctx, transferAgreementObjectType, []string{cryptoMotionCoinID})
+}
+
+// putTransferAgreement records the agreement of the transient data in the implicit collection of the client. The buyer
+// must name itself in its agreement
+func putTransferAgreement(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string, buying bool) error {
+	transientData, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read transient data. %w", err)
+	}
+
+	priceJSON, exists := transientData[priceTransientKey]
+	if len(transientData) == 0 || !exists {
+		return cmcerrors.InvalidInputf("The %s key was not specified in transient data. Please try again", priceTransientKey)
+	}
+
+	agreement := new(CryptoMotionCoinTransferAgreement)
+
+	err = json.Unmarshal(priceJSON, agreement)
+	if err != nil {
+		return cmcerrors.InvalidInputf("Could not unmarshal transient data to type CryptoMotionCoinTransferAgreement. %w", err)
+	}
+
+	if agreement.CryptoMotionCoinID != cryptoMotionCoinID {
+		return cmcerrors.InvalidInputf("The agreement is for asset %s but asset %s was specified", agreement.CryptoMotionCoinID, cryptoMotionCoinID)
+	} else if agreement.TradeID == "" {
+		return cmcerrors.InvalidInputf("The tradeID of the agreement must be specified")
+	} else if agreement.BuyerID == "" {
+		return cmcerrors.InvalidInputf("The buyerID of the agreement must be specified")
+	}
+
+	if buying {
+		clientID, err := ctx.GetClientIdentity().GetID()
+		if err != nil {
+			return cmcerrors.LedgerErrorf("Could not read client identity. %w", err)
+		} else if agreement.BuyerID != clientID {
+			return cmcerrors.InvalidInputf("The buyerID of the agreement must be the ID of the submitting client")
+		}
+	}
+
+	err = validateAmount(agreement.Price)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := canonicalJSON(agreement)
+	if err != nil {
+		return err
+	}
+
+	collectionName, err := getImplicitCollectionName(ctx)
+	if err != nil {
+		return err
+	}
+
+	agreementKey, err := getTransferAgreementKey(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutPrivateData(collectionName, agreementKey, bytes)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return nil
+}
+
+// AgreeToSellCryptoMotionCoin records the selling price of a CryptoMotionCoin held in the implicit collection of the seller
+func (c *CryptoMotionCoinContract) AgreeToSellCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) error {
+	err := authorize(ctx, "AgreeToSellCryptoMotionCoin")
+	if err != nil {
+		return err
+	}
+
+	collectionName, err := getImplicitCollectionName(ctx)
+	if err != nil {
+		return err
+	}
+
+	cryptoMotionCoin, err := readImplicitCryptoMotionCoin(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = assertCreator(ctx, cryptoMotionCoinID, cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotHeld(ctx, collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	return putTransferAgreement(ctx, cryptoMotionCoinID, false)
+}
+
+// readImplicitCryptoMotionCoin reads a CryptoMotionCoin from the implicit collection of the client
+func readImplicitCryptoMotionCoin(ctx contractapi.TransactionContextInterface, collectionName string, cryptoMotionCoinID string) (*CryptoMotionCoin, error) {
+	bytes, err := ctx.GetStub().GetPrivateData(collectionName, cryptoMotionCoinID)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return nil, cmcerrors.NotFoundf("The asset %s does not exist", cryptoMotionCoinID)
+	}
+
+	cryptoMotionCoin, _, err := decodeCryptoMotionCoin(ctx, collectionName, bytes)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not unmarshal private data collection data to type CryptoMotionCoin. %w", err)
+	}
+
+	return cryptoMotionCoin, nil
+}
+
+// AgreeToBuyCryptoMotionCoin records the buying price of a CryptoMotionCoin in the implicit collection of the buyer
+func (c *CryptoMotionCoinContract) AgreeToBuyCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string) error {
+	err := authorize(ctx, "AgreeToBuyCryptoMotionCoin")
+	if err != nil {
+		return err
+	}
+
+	return putTransferAgreement(ctx, cryptoMotionCoinID, true)
+}
+
+// TransferCryptoMotionCoin moves a CryptoMotionCoin from the implicit collection of the seller to the implicit collection of the buyer
+// once both organizations have agreed to the same price. Only the creator of the CryptoMotionCoin or an admin may transfer it.
+// The buyer organization and client named in the agreement become its issuer and creator
+func (c *CryptoMotionCoinContract) TransferCryptoMotionCoin(ctx contractapi.TransactionContextInterface, cryptoMotionCoinID string, buyerMSP string) error {
+	err := authorize(ctx, "TransferCryptoMotionCoin")
+	if err != nil {
+		return err
+	}
+
+	sellerCollection, err := getImplicitCollectionName(ctx)
+	if err != nil {
+		return err
+	}
+
+	buyerCollection := collections.ImplicitPrefix + buyerMSP
+
+	if buyerCollection == sellerCollection {
+		return cmcerrors.InvalidInputf("Cannot transfer asset %s to the organization that holds it", cryptoMotionCoinID)
+	}
+
+	err = assertNotHeld(ctx, sellerCollection, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	agreementKey, err := getTransferAgreementKey(ctx, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	sellerAgreement, err := ctx.GetStub().GetPrivateData(sellerCollection, agreementKey)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if sellerAgreement == nil {
+		return cmcerrors.NotFoundf("No agreement to sell asset %s was found", cryptoMotionCoinID)
+	}
+
+	buyerAgreementHash, err := ctx.GetStub().GetPrivateDataHash(buyerCollection, agreementKey)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if buyerAgreementHash == nil {
+		return cmcerrors.NotFoundf("No agreement to buy asset %s was found for %s", cryptoMotionCoinID, buyerMSP)
+	}
+
+	verified, err := verifyPrivateDataHash(ctx, buyerCollection, agreementKey, sellerAgreement)
+	if err != nil {
+		return err
+	} else if !verified {
+		return cmcerrors.InvalidInputf("The buyer and seller of asset %s have not agreed to the same price", cryptoMotionCoinID)
+	}
+
+	buyerHash, err := ctx.GetStub().GetPrivateDataHash(buyerCollection, cryptoMotionCoinID)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if buyerHash != nil {
+		return cmcerrors.AlreadyExistsf("The organization %s already holds an asset %s", buyerMSP, cryptoMotionCoinID)
+	}
+
+	agreement := new(CryptoMotionCoinTransferAgreement)
+
+	err = json.Unmarshal(sellerAgreement, agreement)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not unmarshal private data collection data to type CryptoMotionCoinTransferAgreement. %w", err)
+	} else if agreement.BuyerID == "" {
+		return cmcerrors.InvalidInputf("The agreement to sell asset %s does not name the buyer. Please agree again", cryptoMotionCoinID)
+	}
+
+	existing, err := readImplicitCryptoMotionCoin(ctx, sellerCollection, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	err = assertCreator(ctx, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	cryptoMotionCoin := *existing
+	cryptoMotionCoin.IssuerMSP = buyerMSP
+	cryptoMotionCoin.CreatorID = agreement.BuyerID
+	cryptoMotionCoin.UpdatedAt = now
+
+	bytes, err := encodeCryptoMotionCoin(&cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutPrivateData(buyerCollection, cryptoMotionCoinID, bytes)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	err = putCryptoMotionCoinIndexes(ctx, buyerCollection, cryptoMotionCoinID, &cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	err = recordTransferredCryptoMotionCoinHistory(ctx, sellerCollection, buyerCollection, cryptoMotionCoinID, bytes, &cryptoMotionCoin)
+	if err != nil {
+		return err
+	}
+
+	err = emitCryptoMotionCoinEvent(ctx, events.Transferred{Payload: events.NewPayload(cryptoMotionCoinID, buyerCollection, getAppraisalHash(bytes)), FromCollection: sellerCollection})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().DelPrivateData(sellerCollection, cryptoMotionCoinID)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	err = delCryptoMotionCoinIndexes(ctx, sellerCollection, cryptoMotionCoinID, existing)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().DelPrivateData(sellerCollection, agreementKey)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	err = ctx.GetStub().DelPrivateData(buyerCollection, agreementKey)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	public, err := getPublicCryptoMotionCoin(ctx, sellerCollection, cryptoMotionCoinID)
+	if err != nil {
+		return err
+	}
+
+	if public != nil {
+		public.OwnerMSP = buyerMSP
+		public.AppraisalHash = getAppraisalHash(bytes)
+
+		err = delPublicCryptoMotionCoin(ctx, sellerCollection, cryptoMotionCoinID)
+		if err != nil {
+			return err
+		}
+
+		err = putPublicCryptoMotionCoin(ctx, buyerCollection, public)
+		if err != nil {
+			return err
+		}
+	}
+
+	return setCryptoMotionCoinEndorsementPolicy(ctx, buyerCollection, cryptoMotionCoinID, []string{buyerMSP}, public != nil)
+}
@@ -0,0 +1,146 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const agreementJSON = `{"buyerID":"x509::CN=buyer1","cryptoMotionCoinID":"cryptoMotionCoinkey","price":100,"tradeID":"trade1"}`
+
+func hashOf(value string) []byte {
+	hash := sha256.Sum256([]byte(value))
+	return hash[:]
+}
+
+func configureTransferStub() (*MockContext, *MockStub) {
+	var nilBytes []byte
+
+	ctx, ms := configureStub()
+	setClientAttribute(ctx, roleAttribute, roleOperator)
+
+	agreementKey, _ := ms.CreateCompositeKey(transferAgreementObjectType, []string{"cryptoMotionCoinkey"})
+	missingAgreementKey, _ := ms.CreateCompositeKey(transferAgreementObjectType, []string{"existingkey"})
+
+	ms.On("GetPrivateData", "_implicit_org_Org1MSP", agreementKey).Return([]byte(agreementJSON), nil)
+	ms.On("GetPrivateData", "_implicit_org_Org1MSP", missingAgreementKey).Return(nilBytes, nil)
+	ms.On("GetPrivateDataHash", "_implicit_org_Org2MSP", agreementKey).Return(hashOf(agreementJSON), nil)
+	ms.On("GetPrivateDataHash", "_implicit_org_Org3MSP", agreementKey).Return(hashOf(`{"buyerID":"x509::CN=buyer1","cryptoMotionCoinID":"cryptoMotionCoinkey","price":90,"tradeID":"trade1"}`), nil)
+	ms.On("GetPrivateDataHash", "_implicit_org_Org4MSP", agreementKey).Return(nilBytes, nil)
+	ms.On("GetPrivateDataHash", "_implicit_org_Org5MSP", agreementKey).Return(hashOf(agreementJSON), nil)
+
+	return ctx, ms
+}
+
+// newTransferredCryptoMotionCoin returns the test CryptoMotionCoin as delivered to the buyer of agreementJSON
+func newTransferredCryptoMotionCoin() *CryptoMotionCoin {
+	cryptoMotionCoin := newTestCryptoMotionCoin()
+	cryptoMotionCoin.IssuerMSP = "Org2MSP"
+	cryptoMotionCoin.CreatorID = "x509::CN=buyer1"
+	cryptoMotionCoin.UpdatedAt = txTime
+
+	return cryptoMotionCoin
+}
+
+func TestAgreeToSellCryptoMotionCoin(t *testing.T) {
+	var err error
+
+	ctx, stub := configureTransferStub()
+	c := new(CryptoMotionCoinContract)
+
+	err = c.AgreeToSellCryptoMotionCoin(ctx, "missingkey")
+	assert.EqualError(t, err, "[NOT_FOUND] The asset missingkey does not exist", "should error when the seller does not hold the asset")
+
+	err = c.AgreeToSellCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The price key was not specified in transient data. Please try again", "should error when no price is given")
+
+	transient[priceTransientKey] = []byte(`{"cryptoMotionCoinID":"other","price":100,"tradeID":"trade1"}`)
+	err = c.AgreeToSellCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The agreement is for asset other but asset cryptoMotionCoinkey was specified", "should error when the agreement is for another asset")
+
+	transient[priceTransientKey] = []byte(`{"cryptoMotionCoinID":"cryptoMotionCoinkey","price":100,"tradeID":"trade1"}`)
+	err = c.AgreeToSellCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The buyerID of the agreement must be specified", "should error when the agreement does not name the buyer")
+
+	transient[priceTransientKey] = []byte(`{"buyerID":"x509::CN=buyer1","cryptoMotionCoinID":"cryptoMotionCoinkey","price":0,"tradeID":"trade1"}`)
+	err = c.AgreeToSellCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount 0 is not valid. It must be a positive integer", "should error when the price is not positive")
+
+	transient[priceTransientKey] = []byte(`{"tradeID":"trade1", "price":100, "cryptoMotionCoinID":"cryptoMotionCoinkey", "buyerID":"x509::CN=buyer1"}`)
+	setClientID(ctx, "x509::CN=user2")
+	err = c.AgreeToSellCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. Only the creator of asset cryptoMotionCoinkey or an admin may modify it", "should only let the creator sell the asset")
+
+	setClientID(ctx, "x509::CN=user1")
+	err = c.AgreeToSellCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when a valid price is given")
+	agreementKey, _ := stub.CreateCompositeKey(transferAgreementObjectType, []string{"cryptoMotionCoinkey"})
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", agreementKey, []byte(agreementJSON))
+}
+
+func TestAgreeToBuyCryptoMotionCoin(t *testing.T) {
+	ctx, stub := configureTransferStub()
+	c := new(CryptoMotionCoinContract)
+
+	transient[priceTransientKey] = []byte(agreementJSON)
+	err := c.AgreeToBuyCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.EqualError(t, err, "[INVALID_INPUT] The buyerID of the agreement must be the ID of the submitting client", "should error when the buyer names another client")
+
+	setClientID(ctx, "x509::CN=buyer1")
+	err = c.AgreeToBuyCryptoMotionCoin(ctx, "cryptoMotionCoinkey")
+	assert.Nil(t, err, "should not return error when a valid price is given")
+	agreementKey, _ := stub.CreateCompositeKey(transferAgreementObjectType, []string{"cryptoMotionCoinkey"})
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", agreementKey, []byte(agreementJSON))
+}
+
+func TestTransferCryptoMotionCoin(t *testing.T) {
+	var err error
+
+	ctx, stub := configureTransferStub()
+	c := new(CryptoMotionCoinContract)
+
+	err = c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org1MSP")
+	assert.EqualError(t, err, "[INVALID_INPUT] Cannot transfer asset cryptoMotionCoinkey to the organization that holds it", "should error when transferring to the holder")
+
+	err = c.TransferCryptoMotionCoin(ctx, "existingkey", "Org2MSP")
+	assert.EqualError(t, err, "[NOT_FOUND] No agreement to sell asset existingkey was found", "should error when the seller has not agreed")
+
+	err = c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org4MSP")
+	assert.EqualError(t, err, "[NOT_FOUND] No agreement to buy asset cryptoMotionCoinkey was found for Org4MSP", "should error when the buyer has not agreed")
+
+	err = c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org3MSP")
+	assert.EqualError(t, err, "[INVALID_INPUT] The buyer and seller of asset cryptoMotionCoinkey have not agreed to the same price", "should error when the prices differ")
+
+	err = c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org5MSP")
+	assert.EqualError(t, err, "[ALREADY_EXISTS] The organization Org5MSP already holds an asset cryptoMotionCoinkey", "should error when the buyer holds an asset of the same ID")
+	stub.AssertNotCalled(t, "PutPrivateData", "_implicit_org_Org5MSP", "cryptoMotionCoinkey", mock.Anything)
+
+	setClientID(ctx, "x509::CN=user2")
+	err = c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org2MSP")
+	assert.EqualError(t, err, "[UNAUTHORIZED] Access denied. Only the creator of asset cryptoMotionCoinkey or an admin may modify it", "should only let the creator transfer the asset")
+
+	setClientID(ctx, "x509::CN=user1")
+	err = c.TransferCryptoMotionCoin(ctx, "cryptoMotionCoinkey", "Org2MSP")
+	assert.Nil(t, err, "should not return error when both organizations agreed to the same price")
+
+	agreementKey, _ := stub.CreateCompositeKey(transferAgreementObjectType, []string{"cryptoMotionCoinkey"})
+	cryptoMotionCoinBytes, _ := encodeCryptoMotionCoin(newTransferredCryptoMotionCoin())
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org2MSP", "cryptoMotionCoinkey", cryptoMotionCoinBytes)
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", "cryptoMotionCoinkey")
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org1MSP", agreementKey)
+	stub.AssertCalled(t, "DelPrivateData", "_implicit_org_Org2MSP", agreementKey)
+
+	sellerEntryBytes, sellerLink := historyLink(&CryptoMotionCoinHistoryEntry{TxID: "txid", Timestamp: txTime, Action: ActionTransfer, ClientID: clientAccount})
+	sellerHistoryKey, _ := getHistoryKey(ctx, "_implicit_org_Org1MSP", "cryptoMotionCoinkey", 0)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org1MSP", sellerHistoryKey, sellerEntryBytes)
+
+	buyerEntryBytes, _ := historyLink(&CryptoMotionCoinHistoryEntry{TxID: "txid", Timestamp: txTime, Action: ActionTransfer, ClientID: clientAccount, ValueHash: getAppraisalHash(cryptoMotionCoinBytes), Value: newTransferredCryptoMotionCoin(), PreviousHash: sellerLink.Hash})
+	buyerHistoryKey, _ := getHistoryKey(ctx, "_implicit_org_Org2MSP", "cryptoMotionCoinkey", 0)
+	stub.AssertCalled(t, "PutPrivateData", "_implicit_org_Org2MSP", buyerHistoryKey, buyerEntryBytes)
+}
@@ -0,0 +1,44 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+// TestTransactionData replays every .txdata file of the transaction_data directory through the contractapi dispatch
+// layer, each on a new ledger, and checks the results and errors the files expect
+func TestTransactionData(t *testing.T) {
+	chaincode, err := contractapi.NewChaincode(new(CryptoMotionCoinContract))
+	require.NoError(t, err, "should create the chaincode")
+
+	paths, err := filepath.Glob(filepath.Join("transaction_data", "*.txdata"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths, "should find .txdata files")
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			transactions, err := ledgertest.LoadTransactionData(path)
+			require.NoError(t, err, "should load the transactions")
+
+			replayer := ledgertest.NewReplayer(chaincode)
+
+			for i := range transactions {
+				transaction := &transactions[i]
+
+				t.Run(transaction.Label(), func(t *testing.T) {
+					assert.NoError(t, replayer.Replay(transaction))
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+const utxoObjectType = "utxo"
+
+// UTXO is an unspent transaction output of the UTXO ledger mode
+type UTXO struct {
+	Key    string `json:"key,omitempty" metadata:"key,optional"`
+	Owner  string `json:"owner"`
+	Amount int64  `json:"amount"`
+}
+
+func getUTXOKey(ctx contractapi.TransactionContextInterface, owner string, key string) (string, error) {
+	return createCompositeKey(ctx, utxoObjectType, []string{owner, key})
+}
+
+func readUTXO(ctx contractapi.TransactionContextInterface, owner string, key string) (*UTXO, error) {
+	utxoKey, err := getUTXOKey(ctx, owner, key)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(utxoKey)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	} else if bytes == nil {
+		return nil, cmcerrors.NotFoundf("The UTXO %s does not exist or is not owned by %s", key, owner)
+	}
+
+	utxo := new(UTXO)
+
+	err = json.Unmarshal(bytes, utxo)
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not unmarshal world state data to type UTXO. %w", err)
+	}
+
+	return utxo, nil
+}
+
+func putUTXO(ctx contractapi.TransactionContextInterface, utxo *UTXO) error {
+	utxoKey, err := getUTXOKey(ctx, utxo.Owner, utxo.Key)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := canonicalJSON(utxo)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(utxoKey, bytes)
+	if err != nil {
+		return cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+	}
+
+	return nil
+}
+
+// MintUTXO creates a new unspent output owned by the submitting client
+func (c *CryptoMotionCoinContract) MintUTXO(ctx contractapi.TransactionContextInterface, amount int64) (*UTXO, error) {
+	err := authorize(ctx, "MintUTXO")
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := getClientAccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	utxo := new(UTXO)
+	utxo.Key = ctx.GetStub().GetTxID() + ".0"
+	utxo.Owner = owner
+	utxo.Amount = amount
+
+	err = putUTXO(ctx, utxo)
+	if err != nil {
+		return nil, err
+	}
+
+	return utxo, nil
+}
+
+// TransferUTXO consumes unspent outputs owned by the submitting client and produces new outputs of the same total amount.
+// Every input is read before it is deleted so a concurrent spend of the same input in the same block fails MVCC validation
+func (c *CryptoMotionCoinContract) TransferUTXO(ctx contractapi.TransactionContextInterface, inputKeys []string, outputs []UTXO) ([]UTXO, error) {
+	err := authorize(ctx, "TransferUTXO")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(inputKeys) == 0 {
+		return nil, cmcerrors.InvalidInputf("At least one input must be specified")
+	} else if len(outputs) == 0 {
+		return nil, cmcerrors.InvalidInputf("At least one output must be specified")
+	}
+
+	owner, err := getClientAccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make(map[string]bool)
+	var totalIn int64
+
+	for _, inputKey := range inputKeys {
+		if spent[inputKey] {
+			return nil, cmcerrors.InvalidInputf("The UTXO %s is spent more than once", inputKey)
+		}
+
+		spent[inputKey] = true
+
+		input, err := readUTXO(ctx, owner, inputKey)
+		if err != nil {
+			return nil, err
+		}
+
+		totalIn, err = addAmounts(totalIn, input.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var totalOut int64
+
+	for i := range outputs {
+		if outputs[i].Owner == "" {
+			return nil, cmcerrors.InvalidInputf("The owner of output %d must be specified", i)
+		}
+
+		err = validateAmount(outputs[i].Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		totalOut, err = addAmounts(totalOut, outputs[i].Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs[i].Key = fmt.Sprintf("%s.%d", ctx.GetStub().GetTxID(), i)
+	}
+
+	if totalIn != totalOut {
+		return nil, cmcerrors.InvalidInputf("The inputs total %d but the outputs total %d", totalIn, totalOut)
+	}
+
+	for _, inputKey := range inputKeys {
+		utxoKey, err := getUTXOKey(ctx, owner, inputKey)
+		if err != nil {
+			return nil, err
+		}
+
+		err = ctx.GetStub().DelState(utxoKey)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not write to world state. %w", err)
+		}
+	}
+
+	for i := range outputs {
+		err = putUTXO(ctx, &outputs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return outputs, nil
+}
+
+// UTXOsOf returns the unspent outputs owned by the given account
+func (c *CryptoMotionCoinContract) UTXOsOf(ctx contractapi.TransactionContextInterface, owner string) ([]UTXO, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(utxoObjectType, []string{owner})
+	if err != nil {
+		return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+	}
+	defer resultsIterator.Close()
+
+	utxos := []UTXO{}
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not read from world state. %w", err)
+		}
+
+		utxo := new(UTXO)
+
+		err = json.Unmarshal(queryResult.Value, utxo)
+		if err != nil {
+			return nil, cmcerrors.LedgerErrorf("Could not unmarshal world state data to type UTXO. %w", err)
+		}
+
+		utxos = append(utxos, *utxo)
+	}
+
+	return utxos, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"newprogmodelgoprivatecontract/ledgertest"
+)
+
+func configureUTXOStub(utxos ...UTXO) (*MockContext, *MockStub) {
+	var nilBytes []byte
+
+	ctx, ms := configureStub()
+
+	owned := make(map[string][]*queryresult.KV)
+
+	for _, utxo := range utxos {
+		utxoKey, _ := ms.CreateCompositeKey(utxoObjectType, []string{utxo.Owner, utxo.Key})
+		utxoBytes, _ := json.Marshal(utxo)
+		ms.On("GetState", utxoKey).Return(utxoBytes, nil)
+		owned[utxo.Owner] = append(owned[utxo.Owner], &queryresult.KV{Key: utxoKey, Value: utxoBytes})
+	}
+
+	for owner, results := range owned {
+		ms.On("GetStateByPartialCompositeKey", utxoObjectType, []string{owner}).Return(&MockIterator{results: results}, nil)
+	}
+
+	ms.On("GetStateByPartialCompositeKey", utxoObjectType, mock.Anything).Return(&MockIterator{}, nil)
+	ms.On("GetState", mock.AnythingOfType("string")).Return(nilBytes, nil)
+
+	return ctx, ms
+}
+
+func TestMintUTXO(t *testing.T) {
+	ctx, stub := configureUTXOStub()
+	c := new(CryptoMotionCoinContract)
+
+	utxo, err := c.MintUTXO(ctx, 0)
+	assert.EqualError(t, err, "[INVALID_INPUT] The amount 0 is not valid. It must be a positive integer", "should error when amount is zero")
+	assert.Nil(t, utxo)
+
+	utxo, err = c.MintUTXO(ctx, 10)
+	assert.Nil(t, err, "should not return error when minting a positive amount")
+	assert.Equal(t, &UTXO{Key: "txid.0", Owner: clientAccount, Amount: 10}, utxo, "should return the new output")
+	utxoKey, _ := stub.CreateCompositeKey(utxoObjectType, []string{clientAccount, "txid.0"})
+	stub.AssertCalled(t, "PutState", utxoKey, []byte(`{"amount":10,"key":"txid.0","owner":"Org1MSP:x509::CN=user1"}`))
+}
+
+func TestTransferUTXO(t *testing.T) {
+	var outputs []UTXO
+	var err error
+
+	ctx, stub := configureUTXOStub(UTXO{Key: "a.0", Owner: clientAccount, Amount: 30}, UTXO{Key: "b.0", Owner: clientAccount, Amount: 20}, UTXO{Key: "c.0", Owner: "other", Amount: 5})
+	c := new(CryptoMotionCoinContract)
+
+	_, err = c.TransferUTXO(ctx, []string{}, []UTXO{{Owner: "other", Amount: 1}})
+	assert.EqualError(t, err, "[INVALID_INPUT] At least one input must be specified", "should error without inputs")
+
+	_, err = c.TransferUTXO(ctx, []string{"a.0"}, []UTXO{})
+	assert.EqualError(t, err, "[INVALID_INPUT] At least one output must be specified", "should error without outputs")
+
+	_, err = c.TransferUTXO(ctx, []string{"a.0", "a.0"}, []UTXO{{Owner: "other", Amount: 60}})
+	assert.EqualError(t, err, "[INVALID_INPUT] The UTXO a.0 is spent more than once", "should reject double spends within a transaction")
+
+	_, err = c.TransferUTXO(ctx, []string{"c.0"}, []UTXO{{Owner: "other", Amount: 5}})
+	assert.EqualError(t, err, fmt.Sprintf("[NOT_FOUND] The UTXO c.0 does not exist or is not owned by %s", clientAccount), "should error when spending outputs of another owner")
+
+	_, err = c.TransferUTXO(ctx, []string{"a.0"}, []UTXO{{Owner: "", Amount: 30}})
+	assert.EqualError(t, err, "[INVALID_INPUT] The owner of output 0 must be specified", "should error when an output has no owner")
+
+	_, err = c.TransferUTXO(ctx, []string{"a.0", "b.0"}, []UTXO{{Owner: "other", Amount: 40}})
+	assert.EqualError(t, err, "[INVALID_INPUT] The inputs total 50 but the outputs total 40", "should error when amounts do not balance")
+
+	outputs, err = c.TransferUTXO(ctx, []string{"a.0", "b.0"}, []UTXO{{Owner: "other", Amount: 35}, {Owner: clientAccount, Amount: 15}})
+	assert.Nil(t, err, "should not return error when inputs and outputs balance")
+	assert.Equal(t, []UTXO{{Key: "txid.0", Owner: "other", Amount: 35}, {Key: "txid.1", Owner: clientAccount, Amount: 15}}, outputs, "should return the new outputs")
+
+	spentKey, _ := stub.CreateCompositeKey(utxoObjectType, []string{clientAccount, "a.0"})
+	stub.AssertCalled(t, "DelState", spentKey)
+	spentKey, _ = stub.CreateCompositeKey(utxoObjectType, []string{clientAccount, "b.0"})
+	stub.AssertCalled(t, "DelState", spentKey)
+	outputKey, _ := stub.CreateCompositeKey(utxoObjectType, []string{"other", "txid.0"})
+	stub.AssertCalled(t, "PutState", outputKey, []byte(`{"amount":35,"key":"txid.0","owner":"other"}`))
+}
+
+func TestUTXOsOf(t *testing.T) {
+	ctx, _ := configureUTXOStub(UTXO{Key: "a.0", Owner: clientAccount, Amount: 30}, UTXO{Key: "b.0", Owner: clientAccount, Amount: 20})
+	c := new(CryptoMotionCoinContract)
+
+	utxos, err := c.UTXOsOf(ctx, clientAccount)
+	assert.Nil(t, err, "should not return error when listing unspent outputs")
+	assert.Equal(t, []UTXO{{Key: "a.0", Owner: clientAccount, Amount: 30}, {Key: "b.0", Owner: clientAccount, Amount: 20}}, utxos, "should return the owned outputs")
+
+	utxos, err = c.UTXOsOf(ctx, "nobody")
+	assert.Nil(t, err, "should not return error when owner has no outputs")
+	assert.Equal(t, []UTXO{}, utxos, "should return an empty list when owner has no outputs")
+}
+
+func TestUTXODispatch(t *testing.T) {
+	ledger := ledgertest.NewLedger()
+	chaincode := newScenarioChaincode(t)
+	issuer := newScenarioIdentity(t, "Org1MSP", "issuer1", roleIssuer)
+	operator := newScenarioIdentity(t, "Org1MSP", "operator1", roleOperator)
+	issuerAccount := getScenarioAccountID(t, issuer)
+	operatorAccount := getScenarioAccountID(t, operator)
+
+	response := ledger.Invoke(chaincode, operator, nil, "MintUTXO", "1000")
+	assert.Equal(t, "[UNAUTHORIZED] Access denied. The issuer role is required for MintUTXO", response.Message, "should only let issuers mint")
+
+	minted := new(UTXO)
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "MintUTXO", "100")), minted))
+	assert.Equal(t, UTXO{Key: minted.Key, Owner: issuerAccount, Amount: 100}, *minted)
+
+	outputs := fmt.Sprintf(`[{"owner":%q,"amount":60},{"owner":%q,"amount":40}]`, operatorAccount, issuerAccount)
+	transferred := []UTXO{}
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, issuer, nil, "TransferUTXO", fmt.Sprintf("[%q]", minted.Key), outputs)), &transferred), "should accept outputs without keys")
+	require.Len(t, transferred, 2)
+
+	owned := []UTXO{}
+	require.NoError(t, json.Unmarshal([]byte(invokeScenario(t, ledger, chaincode, operator, nil, "UTXOsOf", operatorAccount)), &owned))
+	assert.Equal(t, []UTXO{transferred[0]}, owned)
+}
@@ -4,7 +4,126 @@
 
 package main
 
-// CryptoMotionCoin stores a value
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"newprogmodelgoprivatecontract/cmcerrors"
+)
+
+// CryptoMotionCoinStatus is the lifecycle status of a CryptoMotionCoin
+type CryptoMotionCoinStatus string
+
+// Statuses a CryptoMotionCoin can be in
+const (
+	StatusActive   CryptoMotionCoinStatus = "ACTIVE"
+	StatusFrozen   CryptoMotionCoinStatus = "FROZEN"
+	StatusRedeemed CryptoMotionCoinStatus = "REDEEMED"
+)
+
+const maxMetadataEntries = 64
+const maxMetadataKeyLength = 64
+const maxMetadataValueLength = 1024
+
+var denominationPattern = regexp.MustCompile(`^[A-Z0-9]{1,16}$`)
+
+// CryptoMotionCoin stores an amount of a denomination held by an owner
 type CryptoMotionCoin struct {
-	PrivateValue string `json:"privateValue"`
+	Owner        string                 `json:"owner"`
+	Amount       int64                  `json:"amount"`
+	Denomination string                 `json:"denomination"`
+	IssuerMSP    string                 `json:"issuerMSP"`
+	CreatorID    string                 `json:"creatorID,omitempty" metadata:"creatorID,optional"`
+	Status       CryptoMotionCoinStatus `json:"status"`
+	Metadata     map[string]string      `json:"metadata,omitempty" metadata:"metadata,optional"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	UpdatedAt    time.Time              `json:"updatedAt"`
+}
+
+// ValidationError describes why the value of a single CryptoMotionCoin field is not valid
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationErrors lists every invalid field of a CryptoMotionCoin
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+
+	for i, fieldError := range e {
+		messages[i] = fieldError.Error()
+	}
+
+	return "The asset is not valid. " + strings.Join(messages, "; ")
+}
+
+// Validate returns an InvalidInput error wrapping ValidationErrors listing every invalid field, or nil when the CryptoMotionCoin is valid
+func (cmc *CryptoMotionCoin) Validate() error {
+	var errs ValidationErrors
+
+	if cmc.Owner == "" {
+		errs = append(errs, ValidationError{"owner", "must be specified"})
+	}
+
+	if cmc.Amount <= 0 {
+		errs = append(errs, ValidationError{"amount", "must be a positive integer"})
+	}
+
+	if !denominationPattern.MatchString(cmc.Denomination) {
+		errs = append(errs, ValidationError{"denomination", "must be 1 to 16 upper case letters or digits"})
+	}
+
+	if cmc.IssuerMSP == "" {
+		errs = append(errs, ValidationError{"issuerMSP", "must be specified"})
+	}
+
+	switch cmc.Status {
+	case StatusActive, StatusFrozen, StatusRedeemed:
+	default:
+		errs = append(errs, ValidationError{"status", fmt.Sprintf("must be one of %s, %s or %s", StatusActive, StatusFrozen, StatusRedeemed)})
+	}
+
+	if len(cmc.Metadata) > maxMetadataEntries {
+		errs = append(errs, ValidationError{"metadata", fmt.Sprintf("must not have more than %d entries", maxMetadataEntries)})
+	}
+
+	keys := make([]string, 0, len(cmc.Metadata))
+
+	for key := range cmc.Metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "" || len(key) > maxMetadataKeyLength {
+			errs = append(errs, ValidationError{"metadata", fmt.Sprintf("keys must be 1 to %d characters long", maxMetadataKeyLength)})
+			break
+		} else if len(cmc.Metadata[key]) > maxMetadataValueLength {
+			errs = append(errs, ValidationError{"metadata." + key, fmt.Sprintf("must not be longer than %d characters", maxMetadataValueLength)})
+		}
+	}
+
+	if cmc.CreatedAt.IsZero() {
+		errs = append(errs, ValidationError{"createdAt", "must be specified"})
+	}
+
+	if cmc.UpdatedAt.Before(cmc.CreatedAt) {
+		errs = append(errs, ValidationError{"updatedAt", "must not be before createdAt"})
+	}
+
+	if len(errs) > 0 {
+		return cmcerrors.InvalidInputf("%w", errs)
+	}
+
+	return nil
 }
@@ -0,0 +1,146 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package events defines the payloads of the chaincode events emitted by the
+// CryptoMotionCoin chaincode. Every event is JSON encoded and identifies the
+// asset, the collection holding it and the hash of its stored value, which is
+// the same hash peers record for the private data. Private values are never
+// part of an event.
+//
+// Fabric keeps a single event per transaction so every transaction changing a
+// CryptoMotionCoin emits exactly one of the events below.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the version of the payload schema. It is raised whenever a field is removed or changes meaning
+const Version = 1
+
+// The names events are emitted under
+const (
+	CreatedName     = "CryptoMotionCoinCreated"
+	UpdatedName     = "CryptoMotionCoinUpdated"
+	DeletedName     = "CryptoMotionCoinDeleted"
+	PurgedName      = "CryptoMotionCoinPurged"
+	TransferredName = "CryptoMotionCoinTransferred"
+	BatchName       = "CryptoMotionCoinBatch"
+)
+
+// Event is implemented by every event payload
+type Event interface {
+	Name() string
+}
+
+// Payload holds the fields shared by every event
+type Payload struct {
+	Version            int    `json:"version"`
+	CryptoMotionCoinID string `json:"cryptoMotionCoinID"`
+	Collection         string `json:"collection"`
+	ValueHash          string `json:"valueHash,omitempty"`
+}
+
+// NewPayload returns a payload of the current version. The value hash is the hex encoded SHA-256 of the stored value
+func NewPayload(cryptoMotionCoinID string, collection string, valueHash string) Payload {
+	return Payload{Version: Version, CryptoMotionCoinID: cryptoMotionCoinID, Collection: collection, ValueHash: valueHash}
+}
+
+// Created is emitted when a CryptoMotionCoin is created
+type Created struct {
+	Payload
+}
+
+// Name returns the name the event is emitted under
+func (Created) Name() string {
+	return CreatedName
+}
+
+// Updated is emitted when a CryptoMotionCoin is updated
+type Updated struct {
+	Payload
+}
+
+// Name returns the name the event is emitted under
+func (Updated) Name() string {
+	return UpdatedName
+}
+
+// Deleted is emitted when a CryptoMotionCoin is deleted. It carries no value hash
+type Deleted struct {
+	Payload
+}
+
+// Name returns the name the event is emitted under
+func (Deleted) Name() string {
+	return DeletedName
+}
+
+// Purged is emitted when a CryptoMotionCoin and its private history are purged from a collection. It carries no value hash
+type Purged struct {
+	Payload
+}
+
+// Name returns the name the event is emitted under
+func (Purged) Name() string {
+	return PurgedName
+}
+
+// Transferred is emitted when a CryptoMotionCoin moves to the collection of another organization
+type Transferred struct {
+	Payload
+	FromCollection string `json:"fromCollection"`
+}
+
+// Name returns the name the event is emitted under
+func (Transferred) Name() string {
+	return TransferredName
+}
+
+// BatchEntry describes one change of a batch by the name and payload of the event it would have emitted on its own
+type BatchEntry struct {
+	Name string `json:"name"`
+	Payload
+}
+
+// Batch is emitted when a batch of changes is applied in a single transaction
+type Batch struct {
+	Version int          `json:"version"`
+	Entries []BatchEntry `json:"entries"`
+}
+
+// Name returns the name the event is emitted under
+func (Batch) Name() string {
+	return BatchName
+}
+
+// Unmarshal decodes the payload of an event received under the given name
+func Unmarshal(name string, payload []byte) (Event, error) {
+	var event Event
+
+	switch name {
+	case CreatedName:
+		event = new(Created)
+	case UpdatedName:
+		event = new(Updated)
+	case DeletedName:
+		event = new(Deleted)
+	case PurgedName:
+		event = new(Purged)
+	case TransferredName:
+		event = new(Transferred)
+	case BatchName:
+		event = new(Batch)
+	default:
+		return nil, fmt.Errorf("The event %s is not a CryptoMotionCoin event", name)
+	}
+
+	err := json.Unmarshal(payload, event)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal event %s. %s", name, err)
+	}
+
+	return event, nil
+}
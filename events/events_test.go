@@ -0,0 +1,44 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	bytes, _ := json.Marshal(Transferred{Payload: NewPayload("coin1", "_implicit_org_Org2MSP", "abcd"), FromCollection: "_implicit_org_Org1MSP"})
+	assert.Equal(t, `{"version":1,"cryptoMotionCoinID":"coin1","collection":"_implicit_org_Org2MSP","valueHash":"abcd","fromCollection":"_implicit_org_Org1MSP"}`, string(bytes), "should flatten the shared fields")
+
+	bytes, _ = json.Marshal(Deleted{Payload: NewPayload("coin1", "_implicit_org_Org1MSP", "")})
+	assert.Equal(t, `{"version":1,"cryptoMotionCoinID":"coin1","collection":"_implicit_org_Org1MSP"}`, string(bytes), "should omit the value hash of deleted assets")
+}
+
+func TestUnmarshal(t *testing.T) {
+	var event Event
+	var err error
+
+	event, err = Unmarshal(CreatedName, []byte(`{"version":1,"cryptoMotionCoinID":"coin1","collection":"c","valueHash":"abcd"}`))
+	assert.Nil(t, err, "should not return error for a known event")
+	assert.Equal(t, &Created{Payload: NewPayload("coin1", "c", "abcd")}, event)
+	assert.Equal(t, CreatedName, event.Name())
+
+	event, err = Unmarshal(TransferredName, []byte(`{"version":1,"cryptoMotionCoinID":"coin1","collection":"c2","valueHash":"abcd","fromCollection":"c1"}`))
+	assert.Nil(t, err, "should not return error for a transfer event")
+	assert.Equal(t, &Transferred{Payload: NewPayload("coin1", "c2", "abcd"), FromCollection: "c1"}, event)
+
+	event, err = Unmarshal(BatchName, []byte(`{"version":1,"entries":[{"name":"CryptoMotionCoinDeleted","version":1,"cryptoMotionCoinID":"coin1","collection":"c"}]}`))
+	assert.Nil(t, err, "should not return error for a batch event")
+	assert.Equal(t, &Batch{Version: Version, Entries: []BatchEntry{{Name: DeletedName, Payload: NewPayload("coin1", "c", "")}}}, event)
+
+	_, err = Unmarshal("Other", []byte(`{}`))
+	assert.EqualError(t, err, "The event Other is not a CryptoMotionCoin event", "should error for an unknown event")
+
+	_, err = Unmarshal(UpdatedName, []byte(`{`))
+	assert.EqualError(t, err, "Could not unmarshal event CryptoMotionCoinUpdated. unexpected end of JSON input", "should error for a malformed payload")
+}
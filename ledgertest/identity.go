@@ -0,0 +1,89 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/attrmgr"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// Identity is a client of the ledger. It holds a self-signed X.509 certificate carrying its attributes in the extension used
+// by the Fabric CA, so the client identity of its transactions is read by the cid package exactly as on a peer
+type Identity struct {
+	MSPID   string
+	Name    string
+	creator []byte
+}
+
+// NewIdentity returns an identity of the organization with the given common name and certificate attributes
+func NewIdentity(mspID string, name string, attributes map[string]string) (*Identity, error) {
+	if mspID == "" || name == "" {
+		return nil, errors.New("ledgertest: the MSP ID and name of an identity must not be blank")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: name, Organization: []string{mspID}},
+		NotBefore:    StartTime.AddDate(-1, 0, 0),
+		NotAfter:     StartTime.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	if len(attributes) > 0 {
+		value, err := json.Marshal(&attrmgr.Attributes{Attrs: attributes})
+		if err != nil {
+			return nil, err
+		}
+
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: attrmgr.AttrOID, Value: value})
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{MSPID: mspID, Name: name, creator: creator}, nil
+}
+
+// GetCreator returns the serialized identity a peer would read from the signed proposal of a transaction
+func (id *Identity) GetCreator() ([]byte, error) {
+	return id.creator, nil
+}
+
+// ClientIdentity returns the client identity of the transactions submitted by the identity
+func (id *Identity) ClientIdentity() (cid.ClientIdentity, error) {
+	return cid.New(id)
+}
@@ -0,0 +1,51 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgertest
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIdentity(t *testing.T) {
+	identity, err := NewIdentity("Org1MSP", "user1", map[string]string{"cmc.role": "issuer"})
+	require.NoError(t, err, "should create an identity")
+
+	clientIdentity, err := identity.ClientIdentity()
+	require.NoError(t, err, "should parse the certificate of the identity")
+
+	mspID, err := clientIdentity.GetMSPID()
+	assert.NoError(t, err)
+	assert.Equal(t, "Org1MSP", mspID, "should return the MSP ID of the identity")
+
+	role, found, err := clientIdentity.GetAttributeValue("cmc.role")
+	assert.NoError(t, err)
+	assert.True(t, found, "should find the attribute in the certificate")
+	assert.Equal(t, "issuer", role, "should return the value of the attribute")
+
+	_, found, err = clientIdentity.GetAttributeValue("hf.Affiliation")
+	assert.NoError(t, err)
+	assert.False(t, found, "should not find attributes the identity was not given")
+
+	id, err := clientIdentity.GetID()
+	assert.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(id)
+	assert.NoError(t, err)
+	assert.Contains(t, string(decoded), "CN=user1", "should derive the ID from the subject of the certificate")
+
+	other, err := NewIdentity("Org1MSP", "user2", nil)
+	require.NoError(t, err)
+	otherClientIdentity, err := other.ClientIdentity()
+	require.NoError(t, err)
+	otherID, err := otherClientIdentity.GetID()
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, otherID, "should give identities with different names different IDs")
+
+	_, err = NewIdentity("", "user1", nil)
+	assert.EqualError(t, err, "ledgertest: the MSP ID and name of an identity must not be blank", "should require an MSP ID")
+}
@@ -0,0 +1,176 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package ledgertest provides an in-memory ledger for testing chaincode
+// without a Fabric network. A Ledger holds committed world state, private
+// data collections, key-level endorsement policies and events. Each
+// transaction runs against its own Stub, an implementation of
+// shim.ChaincodeStubInterface that behaves like the stub of a peer: reads see
+// the committed ledger only, writes are buffered until the transaction is
+// committed and only the last event set by the transaction is kept.
+//
+// A typical scenario test submits transactions as identities created with
+// NewIdentity
+//
+//	ledger := ledgertest.NewLedger()
+//	issuer, _ := ledgertest.NewIdentity("Org1MSP", "issuer1", map[string]string{"cmc.role": "issuer"})
+//
+//	err := ledger.Submit(issuer, nil, func(ctx contractapi.TransactionContextInterface) error {
+//		return contract.CreateCryptoMotionCoin(ctx, "coin1")
+//	})
+//
+// Transactions can also be dispatched through a chaincode, such as the one
+// returned by contractapi.NewChaincode, with Ledger.Invoke. A Replayer does
+// so for the transactions of .txdata files.
+//
+// A Ledger is not safe for concurrent use.
+package ledgertest
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// ChannelID is the channel reported by the stubs of every Ledger
+const ChannelID = "ledgertest"
+
+// StartTime is the time of the first transaction of a new Ledger. Every later transaction happens one second after the previous one
+var StartTime = time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// Event is a chaincode event committed to the ledger
+type Event struct {
+	TxID    string
+	Name    string
+	Payload []byte
+}
+
+// Ledger is the committed state of an in-memory channel
+type Ledger struct {
+	state          map[string][]byte
+	stateEP        map[string][]byte
+	collections    map[string]map[string][]byte
+	collectionsEP  map[string]map[string][]byte
+	events         []Event
+	now            time.Time
+	transactionNum int
+}
+
+// NewLedger returns an empty Ledger
+func NewLedger() *Ledger {
+	return &Ledger{
+		state:         make(map[string][]byte),
+		stateEP:       make(map[string][]byte),
+		collections:   make(map[string]map[string][]byte),
+		collectionsEP: make(map[string]map[string][]byte),
+		now:           StartTime.Add(-time.Second),
+	}
+}
+
+// Now returns the time of the last transaction
+func (l *Ledger) Now() time.Time {
+	return l.now
+}
+
+// Advance moves the clock of the ledger forward by d, for example to pass a deadline. The next transaction still adds its own second
+func (l *Ledger) Advance(d time.Duration) {
+	l.now = l.now.Add(d)
+}
+
+// State returns the committed value of a world state key, or nil when the key does not exist
+func (l *Ledger) State(key string) []byte {
+	return l.state[key]
+}
+
+// PrivateData returns the committed value of a key of a private data collection, or nil when the key does not exist
+func (l *Ledger) PrivateData(collection string, key string) []byte {
+	return l.collections[collection][key]
+}
+
+// Events returns the events committed so far, oldest first
+func (l *Ledger) Events() []Event {
+	return append([]Event{}, l.events...)
+}
+
+// NewStub starts a transaction submitted by the creator with the given transient data
+func (l *Ledger) NewStub(creator *Identity, transient map[string][]byte) *Stub {
+	l.transactionNum++
+	l.now = l.now.Add(time.Second)
+
+	return newStub(l, creator, transient)
+}
+
+// Submit runs a transaction as the identity and commits it when the function returns no error. The error of the function is returned as is
+func (l *Ledger) Submit(identity *Identity, transient map[string][]byte, transaction func(ctx contractapi.TransactionContextInterface) error) error {
+	stub := l.NewStub(identity, transient)
+
+	ctx, err := NewTransactionContext(stub)
+	if err != nil {
+		return err
+	}
+
+	err = transaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	return stub.Commit()
+}
+
+// Invoke dispatches a transaction to the Invoke function of a chaincode, like a peer does, and commits it when the response
+// status is below shim.ERRORTHRESHOLD. The first argument is the name of the transaction function
+func (l *Ledger) Invoke(chaincode shim.Chaincode, identity *Identity, transient map[string][]byte, args ...string) pb.Response {
+	if identity == nil {
+		return shim.Error("ledgertest: the transaction has no creator")
+	}
+
+	stub := l.NewStub(identity, transient)
+
+	for _, arg := range args {
+		stub.args = append(stub.args, []byte(arg))
+	}
+
+	response := chaincode.Invoke(stub)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return response
+	}
+
+	err := stub.Commit()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return response
+}
+
+// Evaluate runs a transaction as the identity without committing it, like a query sent to a single peer
+func (l *Ledger) Evaluate(identity *Identity, transient map[string][]byte, transaction func(ctx contractapi.TransactionContextInterface) error) error {
+	ctx, err := NewTransactionContext(l.NewStub(identity, transient))
+	if err != nil {
+		return err
+	}
+
+	return transaction(ctx)
+}
+
+// NewTransactionContext returns a transaction context for the stub whose client identity is read from the creator of the stub
+func NewTransactionContext(stub *Stub) (*contractapi.TransactionContext, error) {
+	if stub.creator == nil {
+		return nil, errors.New("ledgertest: the transaction has no creator")
+	}
+
+	clientIdentity, err := stub.creator.ClientIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(clientIdentity)
+
+	return ctx, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgertest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIdentity(t *testing.T) *Identity {
+	identity, err := NewIdentity("Org1MSP", "user1", nil)
+	require.NoError(t, err)
+
+	return identity
+}
+
+func TestSubmit(t *testing.T) {
+	ledger := NewLedger()
+	identity := newTestIdentity(t)
+
+	err := ledger.Submit(identity, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutState("key1", []byte("value1"))
+	})
+	assert.NoError(t, err, "should commit a successful transaction")
+	assert.Equal(t, []byte("value1"), ledger.State("key1"), "should commit the writes of a successful transaction")
+
+	failure := errors.New("some failure")
+	err = ledger.Submit(identity, nil, func(ctx contractapi.TransactionContextInterface) error {
+		ctx.GetStub().PutState("key2", []byte("value2"))
+		return failure
+	})
+	assert.Equal(t, failure, err, "should return the error of a failed transaction")
+	assert.Nil(t, ledger.State("key2"), "should discard the writes of a failed transaction")
+
+	err = ledger.Evaluate(identity, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutState("key3", []byte("value3"))
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, ledger.State("key3"), "should not commit evaluated transactions")
+
+	err = ledger.Submit(nil, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return nil
+	})
+	assert.EqualError(t, err, "ledgertest: the transaction has no creator", "should require an identity")
+}
+
+func TestClock(t *testing.T) {
+	ledger := NewLedger()
+	identity := newTestIdentity(t)
+
+	var times []time.Time
+
+	record := func(ctx contractapi.TransactionContextInterface) error {
+		timestamp, err := ctx.GetStub().GetTxTimestamp()
+		times = append(times, time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC())
+		return err
+	}
+
+	assert.NoError(t, ledger.Submit(identity, nil, record))
+	assert.NoError(t, ledger.Submit(identity, nil, record))
+	ledger.Advance(time.Hour)
+	assert.NoError(t, ledger.Submit(identity, nil, record))
+
+	assert.Equal(t, []time.Time{StartTime, StartTime.Add(time.Second), StartTime.Add(time.Hour + 2*time.Second)}, times, "should time transactions on the clock of the ledger")
+	assert.Equal(t, times[2], ledger.Now(), "should return the time of the last transaction")
+}
+
+func TestEvents(t *testing.T) {
+	ledger := NewLedger()
+	identity := newTestIdentity(t)
+
+	var txID string
+
+	err := ledger.Submit(identity, nil, func(ctx contractapi.TransactionContextInterface) error {
+		txID = ctx.GetStub().GetTxID()
+		ctx.GetStub().SetEvent("First", []byte("1"))
+		return ctx.GetStub().SetEvent("Second", []byte("2"))
+	})
+	assert.NoError(t, err)
+
+	err = ledger.Submit(identity, nil, func(ctx contractapi.TransactionContextInterface) error {
+		ctx.GetStub().SetEvent("Third", []byte("3"))
+		return errors.New("some failure")
+	})
+	assert.Error(t, err)
+
+	assert.Equal(t, []Event{{TxID: txID, Name: "Second", Payload: []byte("2")}}, ledger.Events(), "should keep the last event of committed transactions only")
+
+	err = ledger.Submit(identity, nil, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().SetEvent("", nil)
+	})
+	assert.EqualError(t, err, "event name can not be empty string", "should require an event name")
+}
@@ -0,0 +1,770 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgertest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// compositeKeyNamespace starts every composite key so plain range queries never return them
+const compositeKeyNamespace = "\x00"
+
+func notSupported(feature string) error {
+	return fmt.Errorf("ledgertest: %s is not supported", feature)
+}
+
+func clone(value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+
+	return append([]byte{}, value...)
+}
+
+// Stub is a transaction against a Ledger. Its writes, key-level endorsement policies and event are buffered until Commit.
+// Rich queries support equality selectors, including on nested fields and with the $eq and $exists operators
+type Stub struct {
+	ledger        *Ledger
+	txID          string
+	timestamp     time.Time
+	creator       *Identity
+	transient     map[string][]byte
+	args          [][]byte
+	state         map[string][]byte
+	stateEP       map[string][]byte
+	collections   map[string]map[string][]byte
+	collectionsEP map[string]map[string][]byte
+	event         *Event
+	committed     bool
+}
+
+func newStub(ledger *Ledger, creator *Identity, transient map[string][]byte) *Stub {
+	txID := sha256.Sum256([]byte(ChannelID + strconv.Itoa(ledger.transactionNum)))
+
+	stub := &Stub{
+		ledger:        ledger,
+		txID:          hex.EncodeToString(txID[:]),
+		timestamp:     ledger.now,
+		creator:       creator,
+		transient:     make(map[string][]byte),
+		state:         make(map[string][]byte),
+		stateEP:       make(map[string][]byte),
+		collections:   make(map[string]map[string][]byte),
+		collectionsEP: make(map[string]map[string][]byte),
+	}
+
+	for key, value := range transient {
+		stub.transient[key] = clone(value)
+	}
+
+	return stub
+}
+
+// Commit applies the writes and event of the transaction to the ledger. A transaction can only be committed once
+func (s *Stub) Commit() error {
+	if s.committed {
+		return fmt.Errorf("ledgertest: the transaction %s was already committed", s.txID)
+	}
+
+	s.committed = true
+
+	apply(s.ledger.state, s.state)
+	apply(s.ledger.stateEP, s.stateEP)
+
+	for collection, writes := range s.collections {
+		if s.ledger.collections[collection] == nil {
+			s.ledger.collections[collection] = make(map[string][]byte)
+		}
+
+		apply(s.ledger.collections[collection], writes)
+	}
+
+	for collection, writes := range s.collectionsEP {
+		if s.ledger.collectionsEP[collection] == nil {
+			s.ledger.collectionsEP[collection] = make(map[string][]byte)
+		}
+
+		apply(s.ledger.collectionsEP[collection], writes)
+	}
+
+	if s.event != nil {
+		s.ledger.events = append(s.ledger.events, *s.event)
+	}
+
+	return nil
+}
+
+// apply copies buffered writes to committed values. A nil write deletes the key
+func apply(committed map[string][]byte, writes map[string][]byte) {
+	for key, value := range writes {
+		if value == nil {
+			delete(committed, key)
+		} else {
+			committed[key] = value
+		}
+	}
+}
+
+// Event returns the event set by the transaction, or nil when it did not set one
+func (s *Stub) Event() *Event {
+	return s.event
+}
+
+// GetArgs returns the function name and arguments of a transaction dispatched with Ledger.Invoke
+func (s *Stub) GetArgs() [][]byte {
+	args := make([][]byte, len(s.args))
+
+	for i, arg := range s.args {
+		args[i] = clone(arg)
+	}
+
+	return args
+}
+
+// GetStringArgs returns the function name and arguments of a transaction dispatched with Ledger.Invoke
+func (s *Stub) GetStringArgs() []string {
+	args := make([]string, len(s.args))
+
+	for i, arg := range s.args {
+		args[i] = string(arg)
+	}
+
+	return args
+}
+
+// GetFunctionAndParameters returns the function name and arguments of a transaction dispatched with Ledger.Invoke
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+
+	return args[0], args[1:]
+}
+
+// GetArgsSlice returns the function name and arguments of a transaction dispatched with Ledger.Invoke joined together
+func (s *Stub) GetArgsSlice() ([]byte, error) {
+	return bytes.Join(s.args, nil), nil
+}
+
+// GetTxID returns the ID of the transaction
+func (s *Stub) GetTxID() string {
+	return s.txID
+}
+
+// GetChannelID returns ChannelID
+func (s *Stub) GetChannelID() string {
+	return ChannelID
+}
+
+// InvokeChaincode always fails since a Ledger holds a single chaincode
+func (s *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	return shim.Error(notSupported("invoking another chaincode").Error())
+}
+
+// GetState returns the committed value of a key, or nil when the key does not exist
+func (s *Stub) GetState(key string) ([]byte, error) {
+	return clone(s.ledger.state[key]), nil
+}
+
+// PutState writes a key. Like on a peer, writing an empty value deletes the key
+func (s *Stub) PutState(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+
+	if len(value) == 0 {
+		return s.DelState(key)
+	}
+
+	s.state[key] = clone(value)
+
+	return nil
+}
+
+// DelState deletes a key
+func (s *Stub) DelState(key string) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+
+	s.state[key] = nil
+
+	return nil
+}
+
+// SetStateValidationParameter sets the key-level endorsement policy of a key
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	s.stateEP[key] = clone(ep)
+
+	return nil
+}
+
+// GetStateValidationParameter returns the committed key-level endorsement policy of a key
+func (s *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	return clone(s.ledger.stateEP[key]), nil
+}
+
+// GetStateByRange returns the keys in the range [startKey, endKey), excluding composite keys. Blank keys leave the range open
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	results, err := rangeQuery(s.ledger.state, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIterator(results), nil
+}
+
+// GetStateByRangeWithPagination returns a page of GetStateByRange. The bookmark is the key of the first result of the next page
+func (s *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := rangeQuery(s.ledger.state, startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(results, pageSize, bookmark)
+}
+
+// GetStateByPartialCompositeKey returns the composite keys starting with the object type and attributes
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	results, err := partialCompositeKeyQuery(s.ledger.state, objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIterator(results), nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination returns a page of GetStateByPartialCompositeKey
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := partialCompositeKeyQuery(s.ledger.state, objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(results, pageSize, bookmark)
+}
+
+// CreateCompositeKey combines an object type and attributes into a composite key like a peer does
+func (s *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+// SplitCompositeKey splits a composite key into its object type and attributes
+func (s *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) {
+		return "", nil, fmt.Errorf("ledgertest: %q is not a composite key", compositeKey)
+	}
+
+	components := strings.Split(strings.TrimSuffix(compositeKey[1:], "\x00"), "\x00")
+
+	return components[0], components[1:], nil
+}
+
+// GetQueryResult returns the JSON values matching a CouchDB query
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := richQuery(s.ledger.state, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIterator(results), nil
+}
+
+// GetQueryResultWithPagination returns a page of GetQueryResult
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := richQuery(s.ledger.state, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(results, pageSize, bookmark)
+}
+
+// GetHistoryForKey always fails since a Ledger does not keep the history of keys
+func (s *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return nil, notSupported("the history of keys")
+}
+
+// GetPrivateData returns the committed value of a key of a collection, or nil when the key does not exist
+func (s *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	return clone(s.ledger.collections[collection][key]), nil
+}
+
+// GetPrivateDataHash returns the SHA-256 hash of the committed value of a key of a collection, or nil when the key does not exist
+func (s *Stub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	value, exists := s.ledger.collections[collection][key]
+	if !exists {
+		return nil, nil
+	}
+
+	hash := sha256.Sum256(value)
+
+	return hash[:], nil
+}
+
+func (s *Stub) privateWrites(collection string) map[string][]byte {
+	if s.collections[collection] == nil {
+		s.collections[collection] = make(map[string][]byte)
+	}
+
+	return s.collections[collection]
+}
+
+// PutPrivateData writes a key of a collection. Private data cannot hold an empty value
+func (s *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	} else if key == "" {
+		return errors.New("key must not be an empty string")
+	} else if len(value) == 0 {
+		return fmt.Errorf("ledgertest: the value of private data key %q must not be empty", key)
+	}
+
+	s.privateWrites(collection)[key] = clone(value)
+
+	return nil
+}
+
+// DelPrivateData deletes a key of a collection
+func (s *Stub) DelPrivateData(collection, key string) error {
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	s.privateWrites(collection)[key] = nil
+
+	return nil
+}
+
+// PurgePrivateData deletes a key of a collection. A Ledger keeps no earlier versions of private data so this is the same
+// as DelPrivateData
+func (s *Stub) PurgePrivateData(collection, key string) error {
+	return s.DelPrivateData(collection, key)
+}
+
+// SetPrivateDataValidationParameter sets the key-level endorsement policy of a key of a collection
+func (s *Stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	if s.collectionsEP[collection] == nil {
+		s.collectionsEP[collection] = make(map[string][]byte)
+	}
+
+	s.collectionsEP[collection][key] = clone(ep)
+
+	return nil
+}
+
+// GetPrivateDataValidationParameter returns the committed key-level endorsement policy of a key of a collection
+func (s *Stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return clone(s.ledger.collectionsEP[collection][key]), nil
+}
+
+// GetPrivateDataByRange returns the keys of a collection in the range [startKey, endKey), excluding composite keys
+func (s *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	results, err := rangeQuery(s.ledger.collections[collection], startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIterator(results), nil
+}
+
+// GetPrivateDataByPartialCompositeKey returns the composite keys of a collection starting with the object type and attributes
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	results, err := partialCompositeKeyQuery(s.ledger.collections[collection], objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIterator(results), nil
+}
+
+// GetPrivateDataQueryResult returns the JSON values of a collection matching a CouchDB query
+func (s *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	results, err := richQuery(s.ledger.collections[collection], query)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIterator(results), nil
+}
+
+// GetCreator returns the serialized identity of the client that submitted the transaction
+func (s *Stub) GetCreator() ([]byte, error) {
+	if s.creator == nil {
+		return nil, errors.New("ledgertest: the transaction has no creator")
+	}
+
+	return s.creator.GetCreator()
+}
+
+// GetTransient returns the transient data of the transaction
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	transient := make(map[string][]byte, len(s.transient))
+
+	for key, value := range s.transient {
+		transient[key] = clone(value)
+	}
+
+	return transient, nil
+}
+
+// GetBinding always fails since transactions have no signed proposal
+func (s *Stub) GetBinding() ([]byte, error) {
+	return nil, notSupported("the proposal binding")
+}
+
+// GetDecorations returns no decorations
+func (s *Stub) GetDecorations() map[string][]byte {
+	return map[string][]byte{}
+}
+
+// GetSignedProposal always fails since transactions have no signed proposal
+func (s *Stub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return nil, notSupported("the signed proposal")
+}
+
+// GetTxTimestamp returns the time of the transaction on the clock of the ledger
+func (s *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return &timestamp.Timestamp{Seconds: s.timestamp.Unix(), Nanos: int32(s.timestamp.Nanosecond())}, nil
+}
+
+// SetEvent sets the event of the transaction, replacing any event set before
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be empty string")
+	}
+
+	s.event = &Event{TxID: s.txID, Name: name, Payload: clone(payload)}
+
+	return nil
+}
+
+// collect returns the values of the keys accepted by include, sorted by key
+func collect(values map[string][]byte, include func(key string, value []byte) (bool, error)) ([]*queryresult.KV, error) {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	results := []*queryresult.KV{}
+
+	for _, key := range keys {
+		included, err := include(key, values[key])
+		if err != nil {
+			return nil, err
+		} else if included {
+			results = append(results, &queryresult.KV{Key: key, Value: clone(values[key])})
+		}
+	}
+
+	return results, nil
+}
+
+func rangeQuery(values map[string][]byte, startKey, endKey string) ([]*queryresult.KV, error) {
+	for _, key := range []string{startKey, endKey} {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return nil, fmt.Errorf("first character of the key [%s] contains a null character which is not allowed", key)
+		}
+	}
+
+	return collect(values, func(key string, value []byte) (bool, error) {
+		return !strings.HasPrefix(key, compositeKeyNamespace) && key >= startKey && (endKey == "" || key < endKey), nil
+	})
+}
+
+func partialCompositeKeyQuery(values map[string][]byte, objectType string, attributes []string) ([]*queryresult.KV, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	return collect(values, func(key string, value []byte) (bool, error) {
+		return strings.HasPrefix(key, prefix), nil
+	})
+}
+
+// richQuery returns the JSON values matching a CouchDB query, sorted by key. Like CouchDB it also matches the values of
+// composite keys, and only sorting on _id in ascending order is supported
+func richQuery(values map[string][]byte, query string) ([]*queryresult.KV, error) {
+	var parsed map[string]json.RawMessage
+
+	err := json.Unmarshal([]byte(query), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("ledgertest: the query is not a valid JSON object. %s", err)
+	}
+
+	var selector map[string]interface{}
+
+	for field, value := range parsed {
+		switch field {
+		case "selector":
+			err = json.Unmarshal(value, &selector)
+			if err != nil {
+				return nil, fmt.Errorf("ledgertest: the selector is not a valid JSON object. %s", err)
+			}
+		case "use_index":
+		case "sort":
+			if !sortsByID(value) {
+				return nil, notSupported("the sort field of a query other than on _id in ascending order")
+			}
+		default:
+			return nil, notSupported(fmt.Sprintf("the %s field of a query", field))
+		}
+	}
+
+	if selector == nil {
+		return nil, errors.New("ledgertest: the query must contain a selector")
+	}
+
+	return collect(values, func(key string, value []byte) (bool, error) {
+		var document interface{}
+
+		if json.Unmarshal(value, &document) != nil {
+			return false, nil
+		}
+
+		if object, ok := document.(map[string]interface{}); ok {
+			object["_id"] = key
+		}
+
+		return matches(document, selector)
+	})
+}
+
+// sortsByID reports whether the sort field of a query orders results by _id in ascending order, the order they are read in
+func sortsByID(value json.RawMessage) bool {
+	var fields []interface{}
+
+	if json.Unmarshal(value, &fields) != nil || len(fields) != 1 {
+		return false
+	}
+
+	switch field := fields[0].(type) {
+	case string:
+		return field == "_id"
+	case map[string]interface{}:
+		return len(field) == 1 && field["_id"] == "asc"
+	}
+
+	return false
+}
+
+// matches reports whether a JSON document satisfies a selector. Dotted field names reach nested fields and _id is the
+// key of the document
+func matches(document interface{}, selector map[string]interface{}) (bool, error) {
+	for field, condition := range selector {
+		if field == "$and" {
+			matched, err := matchesAll(document, condition)
+			if err != nil || !matched {
+				return false, err
+			}
+
+			continue
+		} else if strings.HasPrefix(field, "$") {
+			return false, notSupported(fmt.Sprintf("the %s operator", field))
+		}
+
+		value, found := lookup(document, field)
+
+		operators, ok := condition.(map[string]interface{})
+		if !ok {
+			if !found || !reflect.DeepEqual(value, condition) {
+				return false, nil
+			}
+
+			continue
+		}
+
+		for operator, operand := range operators {
+			var matched bool
+
+			switch {
+			case operator == "$eq":
+				matched = found && reflect.DeepEqual(value, operand)
+			case operator == "$exists":
+				matched = found == (operand == true)
+			case operator == "$gt" || operator == "$gte" || operator == "$lt" || operator == "$lte":
+				order, comparable := compare(value, operand)
+				matched = found && comparable && (operator == "$gt" && order > 0 || operator == "$gte" && order >= 0 ||
+					operator == "$lt" && order < 0 || operator == "$lte" && order <= 0)
+			case strings.HasPrefix(operator, "$"):
+				return false, notSupported(fmt.Sprintf("the %s operator", operator))
+			default:
+				nested, err := matches(value, map[string]interface{}{operator: operand})
+				if err != nil {
+					return false, err
+				}
+
+				matched = found && nested
+			}
+
+			if !matched {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func matchesAll(document interface{}, condition interface{}) (bool, error) {
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, errors.New("ledgertest: the $and operator takes an array of selectors")
+	}
+
+	for _, element := range selectors {
+		selector, ok := element.(map[string]interface{})
+		if !ok {
+			return false, errors.New("ledgertest: the $and operator takes an array of selectors")
+		}
+
+		matched, err := matches(document, selector)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// compare orders two strings or two numbers, and reports whether they could be compared
+func compare(value interface{}, operand interface{}) (int, bool) {
+	switch value := value.(type) {
+	case string:
+		if operand, ok := operand.(string); ok {
+			return strings.Compare(value, operand), true
+		}
+	case float64:
+		if operand, ok := operand.(float64); ok {
+			switch {
+			case value < operand:
+				return -1, true
+			case value > operand:
+				return 1, true
+			}
+
+			return 0, true
+		}
+	}
+
+	return 0, false
+}
+
+func lookup(document interface{}, field string) (interface{}, bool) {
+	value := document
+
+	for _, name := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = object[name]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// paginate returns the page of up to pageSize results starting at the bookmark, which is the key of its first result
+func paginate(results []*queryresult.KV, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, fmt.Errorf("ledgertest: the page size %d must be positive", pageSize)
+	}
+
+	start := sort.Search(len(results), func(i int) bool {
+		return results[i].Key >= bookmark
+	})
+
+	results = results[start:]
+	metadata := new(pb.QueryResponseMetadata)
+
+	if len(results) > int(pageSize) {
+		metadata.Bookmark = results[pageSize].Key
+		results = results[:pageSize]
+	}
+
+	metadata.FetchedRecordsCount = int32(len(results))
+
+	return newIterator(results), metadata, nil
+}
+
+// iterator walks the results of a query, which are read from the ledger when the query is made
+type iterator struct {
+	results []*queryresult.KV
+	next    int
+	closed  bool
+}
+
+func newIterator(results []*queryresult.KV) *iterator {
+	return &iterator{results: results}
+}
+
+// HasNext reports whether another result can be read
+func (i *iterator) HasNext() bool {
+	return !i.closed && i.next < len(i.results)
+}
+
+// Next returns the next result
+func (i *iterator) Next() (*queryresult.KV, error) {
+	if !i.HasNext() {
+		return nil, errors.New("ledgertest: the iterator has no more results")
+	}
+
+	i.next++
+
+	return i.results[i.next-1], nil
+}
+
+// Close releases the iterator
+func (i *iterator) Close() error {
+	i.closed = true
+
+	return nil
+}
+
+var _ shim.ChaincodeStubInterface = (*Stub)(nil)
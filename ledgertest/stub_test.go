@@ -0,0 +1,227 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgertest
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commitStub(t *testing.T, ledger *Ledger, writes func(stub *Stub)) {
+	stub := ledger.NewStub(newTestIdentity(t), nil)
+	writes(stub)
+	require.NoError(t, stub.Commit())
+}
+
+func readKeys(t *testing.T, iterator shim.StateQueryIteratorInterface) []string {
+	defer iterator.Close()
+
+	keys := []string{}
+
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		require.NoError(t, err)
+		keys = append(keys, result.Key)
+	}
+
+	return keys
+}
+
+func TestWorldState(t *testing.T) {
+	ledger := NewLedger()
+	stub := ledger.NewStub(newTestIdentity(t), nil)
+
+	assert.NoError(t, stub.PutState("key1", []byte("value1")))
+	value, err := stub.GetState("key1")
+	assert.NoError(t, err)
+	assert.Nil(t, value, "should not read writes of the same transaction")
+
+	assert.NoError(t, stub.Commit())
+	assert.EqualError(t, stub.Commit(), "ledgertest: the transaction "+stub.GetTxID()+" was already committed", "should commit a transaction once")
+
+	stub = ledger.NewStub(newTestIdentity(t), nil)
+	value, err = stub.GetState("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), value, "should read committed writes")
+
+	assert.NoError(t, stub.DelState("key1"))
+	assert.NoError(t, stub.Commit())
+	assert.Nil(t, ledger.State("key1"), "should delete keys")
+
+	assert.EqualError(t, stub.PutState("", []byte("value")), "key must not be an empty string", "should require a key")
+}
+
+func TestPrivateData(t *testing.T) {
+	ledger := NewLedger()
+
+	commitStub(t, ledger, func(stub *Stub) {
+		assert.NoError(t, stub.PutPrivateData("collection1", "key1", []byte("value1")))
+		assert.NoError(t, stub.SetPrivateDataValidationParameter("collection1", "key1", []byte("policy")))
+	})
+
+	stub := ledger.NewStub(newTestIdentity(t), nil)
+
+	value, err := stub.GetPrivateData("collection1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), value, "should read committed private data")
+
+	value, err = stub.GetPrivateData("collection2", "key1")
+	assert.NoError(t, err)
+	assert.Nil(t, value, "should keep collections apart")
+
+	expected := sha256.Sum256([]byte("value1"))
+	hash, err := stub.GetPrivateDataHash("collection1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, expected[:], hash, "should return the SHA-256 hash of the value")
+
+	hash, err = stub.GetPrivateDataHash("collection1", "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, hash, "should not return a hash for missing keys")
+
+	policy, err := stub.GetPrivateDataValidationParameter("collection1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("policy"), policy, "should return the committed endorsement policy")
+
+	assert.EqualError(t, stub.PutPrivateData("collection1", "key2", nil), `ledgertest: the value of private data key "key2" must not be empty`, "should reject empty values")
+	assert.EqualError(t, stub.PutPrivateData("", "key2", []byte("value")), "collection must not be an empty string", "should require a collection")
+
+	assert.NoError(t, stub.PurgePrivateData("collection1", "key1"))
+	assert.NoError(t, stub.Commit())
+	assert.Nil(t, ledger.PrivateData("collection1", "key1"), "should purge private data")
+}
+
+func TestCompositeKeys(t *testing.T) {
+	ledger := NewLedger()
+	stub := ledger.NewStub(newTestIdentity(t), nil)
+
+	key, err := stub.CreateCompositeKey("owner", []string{"user1", "coin1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "\x00owner\x00user1\x00coin1\x00", key, "should create composite keys like a peer")
+
+	objectType, attributes, err := stub.SplitCompositeKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner", objectType)
+	assert.Equal(t, []string{"user1", "coin1"}, attributes, "should split composite keys")
+
+	_, _, err = stub.SplitCompositeKey("coin1")
+	assert.EqualError(t, err, `ledgertest: "coin1" is not a composite key`, "should reject simple keys")
+}
+
+func TestRangeQueries(t *testing.T) {
+	ledger := NewLedger()
+
+	commitStub(t, ledger, func(stub *Stub) {
+		for _, key := range []string{"coin1", "coin2", "coin3", "other"} {
+			stub.PutState(key, []byte("{}"))
+			stub.PutPrivateData("collection1", key, []byte("{}"))
+		}
+
+		for _, owner := range []string{"user1", "user2"} {
+			key, _ := stub.CreateCompositeKey("owner", []string{owner, "coin1"})
+			stub.PutState(key, []byte{0})
+			stub.PutPrivateData("collection1", key, []byte{0})
+		}
+	})
+
+	stub := ledger.NewStub(newTestIdentity(t), nil)
+
+	iterator, err := stub.GetStateByRange("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"coin1", "coin2", "coin3", "other"}, readKeys(t, iterator), "should exclude composite keys from open ranges")
+
+	iterator, err = stub.GetPrivateDataByRange("collection1", "coin2", "other")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"coin2", "coin3"}, readKeys(t, iterator), "should include the start key and exclude the end key")
+
+	_, err = stub.GetStateByRange("\x00owner", "")
+	assert.Error(t, err, "should reject composite keys as bounds")
+
+	iterator, err = stub.GetPrivateDataByPartialCompositeKey("collection1", "owner", []string{"user2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"\x00owner\x00user2\x00coin1\x00"}, readKeys(t, iterator), "should match the prefix of composite keys")
+
+	iterator, metadata, err := stub.GetStateByRangeWithPagination("", "", 2, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"coin1", "coin2"}, readKeys(t, iterator), "should return the first page")
+	assert.Equal(t, int32(2), metadata.FetchedRecordsCount)
+	assert.Equal(t, "coin3", metadata.Bookmark, "should bookmark the first key of the next page")
+
+	iterator, metadata, err = stub.GetStateByRangeWithPagination("", "", 2, metadata.Bookmark)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"coin3", "other"}, readKeys(t, iterator), "should continue from the bookmark")
+	assert.Equal(t, "", metadata.Bookmark, "should not bookmark the last page")
+
+	iterator, err = stub.GetStateByRange("", "")
+	assert.NoError(t, err)
+	assert.NoError(t, iterator.Close())
+	assert.False(t, iterator.HasNext(), "should stop closed iterators")
+	_, err = iterator.Next()
+	assert.EqualError(t, err, "ledgertest: the iterator has no more results")
+}
+
+func TestRichQueries(t *testing.T) {
+	ledger := NewLedger()
+
+	commitStub(t, ledger, func(stub *Stub) {
+		stub.PutPrivateData("collection1", "coin1", []byte(`{"data":{"owner":"user1","amount":10}}`))
+		stub.PutPrivateData("collection1", "coin2", []byte(`{"data":{"owner":"user2","amount":10}}`))
+		stub.PutPrivateData("collection1", "coin3", []byte(`{"data":{"owner":"user1","amount":20,"metadata":{"tier":"gold"}}}`))
+		stub.PutPrivateData("collection1", "raw", []byte("not json"))
+		stub.PutPrivateData("collection1", "\x00history\x00coin1\x00", []byte(`{"value":{"owner":"user1"}}`))
+	})
+
+	stub := ledger.NewStub(newTestIdentity(t), nil)
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`{"selector":{"data.owner":"user1"}}`, []string{"coin1", "coin3"}},
+		{`{"selector":{"data":{"owner":"user1","amount":20}}}`, []string{"coin3"}},
+		{`{"selector":{"data.amount":{"$eq":10}}}`, []string{"coin1", "coin2"}},
+		{`{"selector":{"data.metadata":{"$exists":false}}}`, []string{"\x00history\x00coin1\x00", "coin1", "coin2"}},
+		{`{"selector":{"data.metadata.tier":"gold"},"use_index":"_design/owner"}`, []string{"coin3"}},
+		{`{"selector":{"data.amount":{"$gt":10}}}`, []string{"coin3"}},
+		{`{"selector":{"data.amount":{"$lte":10}}}`, []string{"coin1", "coin2"}},
+		{`{"selector":{"$and":[{"data.owner":"user1"},{"_id":{"$gte":"coin2"}}]}}`, []string{"coin3"}},
+		{`{"selector":{"_id":{"$lt":"coin2"}}}`, []string{"\x00history\x00coin1\x00", "coin1"}},
+		{`{"selector":{"value.owner":"user1"}}`, []string{"\x00history\x00coin1\x00"}},
+		{`{"selector":{"data.owner":"user1"},"sort":[{"_id":"asc"}]}`, []string{"coin1", "coin3"}},
+		{`{"selector":{"data.owner":"user1"},"sort":["_id"]}`, []string{"coin1", "coin3"}},
+	}
+
+	for _, test := range tests {
+		iterator, err := stub.GetPrivateDataQueryResult("collection1", test.query)
+		assert.NoError(t, err, test.query)
+		assert.Equal(t, test.expected, readKeys(t, iterator), "should match %s", test.query)
+	}
+
+	_, err := stub.GetPrivateDataQueryResult("collection1", `{"selector":{"data.owner":{"$regex":"^user"}}}`)
+	assert.EqualError(t, err, "ledgertest: the $regex operator is not supported", "should reject unsupported operators")
+
+	_, err = stub.GetPrivateDataQueryResult("collection1", `{"selector":{},"sort":["data.owner"]}`)
+	assert.EqualError(t, err, "ledgertest: the sort field of a query other than on _id in ascending order is not supported", "should reject unsupported sorts")
+
+	_, err = stub.GetPrivateDataQueryResult("collection1", `{"selector":{},"limit":2}`)
+	assert.EqualError(t, err, "ledgertest: the limit field of a query is not supported", "should reject unsupported query fields")
+
+	_, err = stub.GetQueryResult(`{}`)
+	assert.EqualError(t, err, "ledgertest: the query must contain a selector", "should require a selector")
+}
+
+func TestTransient(t *testing.T) {
+	transient := map[string][]byte{"key": []byte("value")}
+	stub := NewLedger().NewStub(newTestIdentity(t), transient)
+
+	transient["key"][0] = 'V'
+
+	value, err := stub.GetTransient()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"key": []byte("value")}, value, "should copy the transient data of the transaction")
+}
@@ -0,0 +1,227 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// TransactionIdentity describes the client submitting a transaction of a .txdata file
+type TransactionIdentity struct {
+	MSPID      string            `json:"mspID"`
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// TransactionData is one transaction of a .txdata file, the format the IBM Blockchain Platform extension for VS Code uses
+// to submit transactions. Arguments and transient values that are not JSON strings are passed as their JSON text.
+//
+// Three optional fields extend the format for replays. Identity names the client submitting the transaction.
+// ExpectedResult is compared with the JSON response of a successful transaction; objects match when every field they
+// list matches, so fields such as timestamps can be left out. ExpectedError is a text the error message of a failed
+// transaction must contain, such as a full message or just an error code like [NOT_FOUND]. A transaction without
+// ExpectedError must succeed
+type TransactionData struct {
+	TransactionName  string                     `json:"transactionName"`
+	TransactionLabel string                     `json:"transactionLabel"`
+	Arguments        []json.RawMessage          `json:"arguments"`
+	TransientData    map[string]json.RawMessage `json:"transientData"`
+	Identity         *TransactionIdentity       `json:"identity,omitempty"`
+	ExpectedResult   json.RawMessage            `json:"expectedResult,omitempty"`
+	ExpectedError    *string                    `json:"expectedError,omitempty"`
+}
+
+// LoadTransactionData reads the transactions of a .txdata file
+func LoadTransactionData(path string) ([]TransactionData, error) {
+	document, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	var transactions []TransactionData
+
+	err = decoder.Decode(&transactions)
+	if err != nil {
+		return nil, fmt.Errorf("ledgertest: %s is not a valid .txdata file. %s", path, err)
+	}
+
+	for i, transaction := range transactions {
+		if transaction.TransactionName == "" {
+			return nil, fmt.Errorf("ledgertest: transaction %d of %s has no transactionName", i, path)
+		}
+	}
+
+	return transactions, nil
+}
+
+// Label returns the label of the transaction, or its name when it has no label
+func (t *TransactionData) Label() string {
+	if t.TransactionLabel == "" {
+		return t.TransactionName
+	}
+
+	return t.TransactionLabel
+}
+
+// textOf returns the content of a JSON string or the JSON text of any other value
+func textOf(value json.RawMessage) string {
+	var text string
+
+	if json.Unmarshal(value, &text) == nil {
+		return text
+	}
+
+	return string(value)
+}
+
+// Args returns the transaction name followed by the arguments of the transaction
+func (t *TransactionData) Args() []string {
+	args := []string{t.TransactionName}
+
+	for _, argument := range t.Arguments {
+		args = append(args, textOf(argument))
+	}
+
+	return args
+}
+
+// Transient returns the transient data of the transaction
+func (t *TransactionData) Transient() map[string][]byte {
+	transient := make(map[string][]byte, len(t.TransientData))
+
+	for key, value := range t.TransientData {
+		transient[key] = []byte(textOf(value))
+	}
+
+	return transient
+}
+
+// Check compares the response of the transaction with its expected result or error
+func (t *TransactionData) Check(response pb.Response) error {
+	if response.Status >= shim.ERRORTHRESHOLD {
+		if t.ExpectedError == nil {
+			return fmt.Errorf("the transaction failed: %s", response.Message)
+		} else if !strings.Contains(response.Message, *t.ExpectedError) {
+			return fmt.Errorf("the transaction failed with %q instead of an error containing %q", response.Message, *t.ExpectedError)
+		}
+
+		return nil
+	}
+
+	if t.ExpectedError != nil {
+		return fmt.Errorf("the transaction succeeded but an error containing %q was expected", *t.ExpectedError)
+	}
+
+	if len(t.ExpectedResult) == 0 {
+		return nil
+	}
+
+	var expected, actual interface{}
+
+	err := json.Unmarshal(t.ExpectedResult, &expected)
+	if err != nil {
+		return fmt.Errorf("the expected result is not valid JSON. %s", err)
+	}
+
+	if json.Unmarshal(response.Payload, &actual) != nil {
+		actual = string(response.Payload)
+	}
+
+	if !contains(actual, expected) {
+		return fmt.Errorf("the transaction returned %s instead of %s", response.Payload, t.ExpectedResult)
+	}
+
+	return nil
+}
+
+// contains reports whether a JSON value matches an expected value. Objects match when every expected field matches
+func contains(actual interface{}, expected interface{}) bool {
+	expectedObject, ok := expected.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(actual, expected)
+	}
+
+	actualObject, ok := actual.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for field, value := range expectedObject {
+		actualValue, exists := actualObject[field]
+		if !exists || !contains(actualValue, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Replayer submits the transactions of .txdata files to a chaincode on a Ledger, in order. Transactions without an identity
+// are submitted by DefaultIdentity. Identities with the same MSP ID, name and attributes share a certificate
+type Replayer struct {
+	Ledger          *Ledger
+	Chaincode       shim.Chaincode
+	DefaultIdentity TransactionIdentity
+	identities      map[string]*Identity
+}
+
+// NewReplayer returns a Replayer submitting transactions to the chaincode on a new Ledger as user1 of Org1MSP by default
+func NewReplayer(chaincode shim.Chaincode) *Replayer {
+	return &Replayer{
+		Ledger:          NewLedger(),
+		Chaincode:       chaincode,
+		DefaultIdentity: TransactionIdentity{MSPID: "Org1MSP", Name: "user1"},
+		identities:      make(map[string]*Identity),
+	}
+}
+
+func (r *Replayer) getIdentity(transactionIdentity *TransactionIdentity) (*Identity, error) {
+	if transactionIdentity == nil {
+		transactionIdentity = &r.DefaultIdentity
+	}
+
+	key, err := json.Marshal(transactionIdentity)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, exists := r.identities[string(key)]
+	if exists {
+		return identity, nil
+	}
+
+	identity, err = NewIdentity(transactionIdentity.MSPID, transactionIdentity.Name, transactionIdentity.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	r.identities[string(key)] = identity
+
+	return identity, nil
+}
+
+// Replay submits a transaction and checks its response. Successful transactions are committed whether or not they return
+// the expected result
+func (r *Replayer) Replay(transaction *TransactionData) error {
+	identity, err := r.getIdentity(transaction.Identity)
+	if err != nil {
+		return err
+	}
+
+	response := r.Ledger.Invoke(r.Chaincode, identity, transaction.Transient(), transaction.Args()...)
+
+	return transaction.Check(response)
+}
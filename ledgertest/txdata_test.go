@@ -0,0 +1,150 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgertest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoChaincode stores the transient data of put under its argument and returns what get or creator read back
+type echoChaincode struct{}
+
+func (echoChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (echoChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+
+	switch function {
+	case "put":
+		transient, _ := stub.GetTransient()
+		stub.PutState(args[0], transient["value"])
+		return shim.Success(nil)
+	case "get":
+		value, _ := stub.GetState(args[0])
+		return shim.Success(value)
+	default:
+		return shim.Error("[NOT_FOUND] Unknown function " + function)
+	}
+}
+
+// writeTransactionData writes a .txdata file the caller removes
+func writeTransactionData(t *testing.T, document string) string {
+	file, err := ioutil.TempFile("", "*.txdata")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString(document)
+	require.NoError(t, err)
+
+	return file.Name()
+}
+
+func TestInvoke(t *testing.T) {
+	ledger := NewLedger()
+	identity := newTestIdentity(t)
+
+	response := ledger.Invoke(echoChaincode{}, identity, map[string][]byte{"value": []byte("value1")}, "put", "key1")
+	assert.Equal(t, int32(shim.OK), response.Status, "should dispatch the function")
+	assert.Equal(t, []byte("value1"), ledger.State("key1"), "should commit successful transactions")
+
+	response = ledger.Invoke(echoChaincode{}, identity, nil, "get", "key1")
+	assert.Equal(t, []byte("value1"), response.Payload, "should pass the arguments")
+
+	response = ledger.Invoke(echoChaincode{}, identity, nil, "other")
+	assert.Equal(t, "[NOT_FOUND] Unknown function other", response.Message, "should return the error of the chaincode")
+
+	response = ledger.Invoke(echoChaincode{}, nil, nil, "get", "key1")
+	assert.Equal(t, "ledgertest: the transaction has no creator", response.Message, "should require an identity")
+}
+
+func TestLoadTransactionData(t *testing.T) {
+	path := writeTransactionData(t, `[
+		{"transactionName": "put", "arguments": ["key1"], "transientData": {"value": {"amount": 10}}},
+		{"transactionName": "get", "transactionLabel": "Read key1", "arguments": ["key1", 5, true], "transientData": {}, "expectedResult": {"amount": 10}}
+	]`)
+	defer os.Remove(path)
+
+	transactions, err := LoadTransactionData(path)
+	require.NoError(t, err, "should load the transactions")
+	require.Len(t, transactions, 2)
+
+	assert.Equal(t, "put", transactions[0].Label(), "should label transactions by name when they have no label")
+	assert.Equal(t, "Read key1", transactions[1].Label())
+	assert.Equal(t, map[string][]byte{"value": []byte(`{"amount": 10}`)}, transactions[0].Transient(), "should pass JSON values as their text")
+	assert.Equal(t, []string{"get", "key1", "5", "true"}, transactions[1].Args(), "should prefix the arguments with the transaction name")
+
+	path = writeTransactionData(t, `[{"transactionName": "get", "expectedEror": "typo"}]`)
+	defer os.Remove(path)
+
+	_, err = LoadTransactionData(path)
+	assert.Error(t, err, "should reject unknown fields")
+
+	path = writeTransactionData(t, `[{"arguments": []}]`)
+	defer os.Remove(path)
+
+	_, err = LoadTransactionData(path)
+	assert.Contains(t, err.Error(), "transaction 0 of", "should require a transaction name")
+}
+
+func TestCheck(t *testing.T) {
+	expectedError := "[NOT_FOUND]"
+
+	tests := []struct {
+		transaction TransactionData
+		response    pb.Response
+		expected    string
+	}{
+		{TransactionData{}, shim.Success(nil), ""},
+		{TransactionData{}, shim.Error("failure"), "the transaction failed: failure"},
+		{TransactionData{ExpectedError: &expectedError}, shim.Error("[NOT_FOUND] The asset 001 does not exist"), ""},
+		{TransactionData{ExpectedError: &expectedError}, shim.Error("[INVALID_INPUT] Bad"), `the transaction failed with "[INVALID_INPUT] Bad" instead of an error containing "[NOT_FOUND]"`},
+		{TransactionData{ExpectedError: &expectedError}, shim.Success(nil), `the transaction succeeded but an error containing "[NOT_FOUND]" was expected`},
+		{TransactionData{ExpectedResult: json.RawMessage(`false`)}, shim.Success([]byte("false")), ""},
+		{TransactionData{ExpectedResult: json.RawMessage(`"user1"`)}, shim.Success([]byte("user1")), ""},
+		{TransactionData{ExpectedResult: json.RawMessage(`{"owner":"user1"}`)}, shim.Success([]byte(`{"owner":"user1","amount":100}`)), ""},
+		{TransactionData{ExpectedResult: json.RawMessage(`{"owner":"user2"}`)}, shim.Success([]byte(`{"owner":"user1"}`)), `the transaction returned {"owner":"user1"} instead of {"owner":"user2"}`},
+		{TransactionData{ExpectedResult: json.RawMessage(`[1,2]`)}, shim.Success([]byte(`[1,2,3]`)), "the transaction returned [1,2,3] instead of [1,2]"},
+	}
+
+	for i, test := range tests {
+		err := test.transaction.Check(test.response)
+
+		if test.expected == "" {
+			assert.NoError(t, err, "case %d", i)
+		} else {
+			assert.EqualError(t, err, test.expected, "case %d", i)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	path := writeTransactionData(t, `[
+		{"transactionName": "put", "arguments": ["key1"], "transientData": {"value": "value1"}, "identity": {"mspID": "Org2MSP", "name": "user2"}},
+		{"transactionName": "get", "arguments": ["key1"], "transientData": {}, "expectedResult": "value1"},
+		{"transactionName": "delete", "arguments": ["key1"], "transientData": {}, "expectedError": "[NOT_FOUND]"}
+	]`)
+	defer os.Remove(path)
+
+	transactions, err := LoadTransactionData(path)
+	require.NoError(t, err)
+
+	replayer := NewReplayer(echoChaincode{})
+
+	for i := range transactions {
+		assert.NoError(t, replayer.Replay(&transactions[i]), "should replay transaction %d", i)
+	}
+
+	assert.Len(t, replayer.identities, 2, "should create an identity per distinct client")
+}
@@ -5,28 +5,67 @@
 package main
 
 import (
+	"os"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
-	"github.com/hyperledger/fabric-contract-api-go/metadata"
 )
 
+// version is the version of the chaincode, set at build time with -ldflags "-X main.version=1.2.3"
+var version = "0.0.1"
+
+// beforeTransaction runs before every transaction dispatched to the contract. It starts the trace of the transaction
+// and then refuses the transactions of disabled features
+func beforeTransaction(ctx *transactionContext) error {
+	err := startTransactionTrace(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkFeatureEnabled(ctx)
+}
+
 func main() {
+	config, err := loadChaincodeConfig(os.Getenv)
+	if err != nil {
+		panic("Could not load the chaincode configuration. " + err.Error())
+	}
+
+	activeConfig = config
+
 	cryptoMotionCoinContract := new(CryptoMotionCoinContract)
-	cryptoMotionCoinContract.Info.Version = "0.0.1"
-	cryptoMotionCoinContract.Info.Description = "My Private Data Smart Contract"
-	cryptoMotionCoinContract.Info.License = new(metadata.LicenseMetadata)
-	cryptoMotionCoinContract.Info.License.Name = "Apache-2.0"
-	cryptoMotionCoinContract.Info.Contact = new(metadata.ContactMetadata)
-	cryptoMotionCoinContract.Info.Contact.Name = "John Doe"
+	cryptoMotionCoinContract.TransactionContextHandler = new(transactionContext)
+	cryptoMotionCoinContract.BeforeTransaction = beforeTransaction
+	cryptoMotionCoinContract.AfterTransaction = afterTransaction
+	config.applyTo(cryptoMotionCoinContract)
 
 	chaincode, err := contractapi.NewChaincode(cryptoMotionCoinContract)
-	chaincode.Info.Title = "Create-BlockchainNetwork-IBPV20 chaincode"
-	chaincode.Info.Version = "0.0.1"
+	if err != nil {
+		panic("Could not create chaincode from CryptoMotionCoinContract. " + err.Error())
+	}
 
+	chaincode.Info.Title = config.Contract.Title
+	chaincode.Info.Version = version
+
+	serverConfig, err := getServerConfig(os.Getenv)
 	if err != nil {
-		panic("Could not create chaincode from CryptoMotionCoinContract." + err.Error())
+		panic("Could not read the chaincode server configuration. " + err.Error())
+	}
+
+	writeLog("info", "Starting chaincode", logFields{"title": config.Contract.Title, "version": version})
+
+	tracedChaincode := &tracingChaincode{chaincode}
+
+	if serverConfig != nil {
+		err = serveChaincode(serverConfig, tracedChaincode)
+		if err != nil {
+			panic("Failed to serve chaincode. " + err.Error())
+		}
+
+		return
 	}
 
-	err = chaincode.Start()
+	err = shim.Start(tracedChaincode)
 
 	if err != nil {
 		panic("Failed to start chaincode. " + err.Error())